@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrTokenExpired is returned for JWTs past their expiry.
+	ErrTokenExpired = errors.New("token expired")
+	// ErrTokenReused is returned when a refresh token that was already
+	// rotated out is presented again. Its session is revoked, as the token
+	// has probably been stolen.
+	ErrTokenReused = errors.New("refresh token reused")
+)
+
+// TokenPair is the response of POST /token.
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int `json:"expires_in"`
+}
+
+// IssueTokens starts a session for user on an API client. Bearer sessions
+// are stored like cookie sessions, so they are listed and revoked the same
+// way; their SessionID is the hash of the current refresh token's id.
+func (m *Manager) IssueTokens(ctx context.Context, user models.User, client Client) (*TokenPair, error) {
+	refreshID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.Session{
+		ID:         primitive.NewObjectID(),
+		SessionID:  hashSecret(refreshID),
+		UserID:     user.ID,
+		Role:       user.Role,
+		Issued:     now,
+		LastSeenAt: now,
+		ExpiresAt:  m.expiry(now, now),
+		Device:     DeviceName(client.UserAgent),
+		UserAgent:  truncate(client.UserAgent, maxUserAgent),
+		IP:         client.IP,
+	}
+	if err := m.Repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return m.tokens(session, user.Role, refreshID, now)
+}
+
+// Refresh exchanges a refresh token for a new pair. Every refresh token
+// works once: presenting one again revokes its session. The user is looked
+// up again so role changes and deleted accounts take effect.
+func (m *Manager) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := m.Keys.ParseJWT(refreshToken, UseRefresh)
+	if err != nil {
+		return nil, err
+	}
+	id, err := primitive.ObjectIDFromHex(claims.Session)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	session, err := m.Repo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !session.RevokedAt.IsZero() {
+		return nil, ErrSessionRevoked
+	}
+	if !now.Before(session.ExpiresAt) {
+		return nil, ErrSessionExpired
+	}
+	if session.SessionID != hashSecret(claims.ID) {
+		return nil, m.reused(ctx, session)
+	}
+
+	user, err := m.Users.GetByID(ctx, session.UserID.Hex())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, m.revoke(ctx, session, ErrInvalidToken)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refreshID, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	session.SessionID = hashSecret(refreshID)
+	session.Role = user.Role
+	session.LastSeenAt = now
+	session.ExpiresAt = m.expiry(session.Issued, now)
+	err = m.Repo.Rotate(ctx, session.ID, hashSecret(claims.ID), session)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// a concurrent refresh with the same token got there first
+		return nil, m.reused(ctx, session)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return m.tokens(session, user.Role, refreshID, now)
+}
+
+func (m *Manager) reused(ctx context.Context, session *models.Session) error {
+	return m.revoke(ctx, session, ErrTokenReused)
+}
+
+// revoke ends session and returns reason, or the error revoking it.
+func (m *Manager) revoke(ctx context.Context, session *models.Session, reason error) error {
+	if err := m.Repo.RevokeByID(ctx, session.UserID, session.ID); err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+	return reason
+}
+
+func (m *Manager) tokens(session *models.Session, role, refreshID string, now time.Time) (*TokenPair, error) {
+	access, err := m.Keys.SignJWT(Claims{
+		Issuer:    issuer,
+		Subject:   session.UserID.Hex(),
+		Session:   session.ID.Hex(),
+		Use:       UseAccess,
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(m.Config.AccessTTL).Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := m.Keys.SignJWT(Claims{
+		Issuer:    issuer,
+		Subject:   session.UserID.Hex(),
+		Session:   session.ID.Hex(),
+		ID:        refreshID,
+		Use:       UseRefresh,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: session.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(m.Config.AccessTTL.Seconds()),
+	}, nil
+}
+
+// BearerToken returns the token of an "Authorization: Bearer" header, or "".
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// AuthenticateBearer validates an access token and returns its session.
+// The session is looked up on every request, so revoking it or changing
+// the user's role takes effect at once rather than when the token expires.
+func (m *Manager) AuthenticateBearer(ctx context.Context, token string) (*models.Session, error) {
+	claims, err := m.Keys.ParseJWT(token, UseAccess)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.Subject)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	id, err := primitive.ObjectIDFromHex(claims.Session)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	session, err := m.Repo.GetByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrInvalidToken
+	}
+	if !session.RevokedAt.IsZero() {
+		return nil, ErrSessionRevoked
+	}
+	if !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrSessionExpired
+	}
+	return session, nil
+}
+
+// AuthenticateRequest authenticates a request by its bearer token if it
+// has one, and by its session cookie otherwise.
+func (m *Manager) AuthenticateRequest(w http.ResponseWriter, r *http.Request) (*models.Session, error) {
+	if token := BearerToken(r); token != "" {
+		return m.AuthenticateBearer(r.Context(), token)
+	}
+	return m.Authenticate(w, r)
+}
+
+func randomID() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// memSessions is a SessionStore in memory.
+type memSessions struct {
+	mu       sync.Mutex
+	sessions map[primitive.ObjectID]models.Session
+}
+
+func (s *memSessions) Create(ctx context.Context, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if session.ID.IsZero() {
+		session.ID = primitive.NewObjectID()
+	}
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *memSessions) GetBySessionID(ctx context.Context, sessionID string) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, session := range s.sessions {
+		if session.SessionID == sessionID {
+			return &session, nil
+		}
+	}
+	return nil, mongo.ErrNoDocuments
+}
+
+func (s *memSessions) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &session, nil
+}
+
+func (s *memSessions) Touch(ctx context.Context, sessionID string, seen time.Time, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		if session.SessionID == sessionID {
+			session.LastSeenAt, session.ExpiresAt = seen, expires
+			s.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (s *memSessions) Rotate(ctx context.Context, id primitive.ObjectID, old string, session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current, ok := s.sessions[id]
+	if !ok || current.SessionID != old || !current.RevokedAt.IsZero() {
+		return mongo.ErrNoDocuments
+	}
+	s.sessions[id] = *session
+	return nil
+}
+
+func (s *memSessions) Revoke(ctx context.Context, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, session := range s.sessions {
+		if session.SessionID == sessionID && session.RevokedAt.IsZero() {
+			session.RevokedAt = time.Now()
+			s.sessions[id] = session
+		}
+	}
+	return nil
+}
+
+func (s *memSessions) RevokeByID(ctx context.Context, userID, id primitive.ObjectID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok || session.UserID != userID || !session.RevokedAt.IsZero() {
+		return mongo.ErrNoDocuments
+	}
+	session.RevokedAt = time.Now()
+	s.sessions[id] = session
+	return nil
+}
+
+// setRole changes the role recorded in a session, as
+// repository.SessionRepository.SetRole does when a user's role changes.
+func (s *memSessions) setRole(id primitive.ObjectID, role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session := s.sessions[id]
+	session.Role = role
+	s.sessions[id] = session
+}
+
+// memUsers is a UserStore in memory.
+type memUsers map[string]models.User
+
+func (u memUsers) GetByID(ctx context.Context, id string) (*models.User, error) {
+	user, ok := u[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+	return &user, nil
+}
+
+func testManager(t *testing.T) (*Manager, *memSessions, models.User) {
+	t.Helper()
+	user := models.User{ID: primitive.NewObjectID(), Role: "member"}
+	sessions := &memSessions{sessions: make(map[primitive.ObjectID]models.Session)}
+	users := memUsers{user.ID.Hex(): user}
+	return NewManager(mustKeyring(t, testKey("a", 1)), sessions, users, DefaultConfig()), sessions, user
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	m, sessions, user := testManager(t)
+	ctx := context.Background()
+
+	first, err := m.IssueTokens(ctx, user, Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	// presenting the old token again looks like theft and ends the session
+	if _, err := m.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrTokenReused) {
+		t.Fatalf("reused refresh token: got %v, want ErrTokenReused", err)
+	}
+	if _, err := m.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("refresh after reuse: got %v, want ErrSessionRevoked", err)
+	}
+	if _, err := m.AuthenticateBearer(ctx, second.AccessToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("access token after reuse: got %v, want ErrSessionRevoked", err)
+	}
+	if len(sessions.sessions) != 1 {
+		t.Errorf("refreshing created %d sessions, want 1", len(sessions.sessions))
+	}
+}
+
+func TestRefreshRejectsAccessToken(t *testing.T) {
+	m, _, user := testManager(t)
+	tokens, err := m.IssueTokens(context.Background(), user, Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Refresh(context.Background(), tokens.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("got %v, want ErrInvalidToken", err)
+	}
+	if _, err := m.AuthenticateBearer(context.Background(), tokens.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh token as access token: got %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestAuthenticateBearerChecksSession(t *testing.T) {
+	m, sessions, user := testManager(t)
+	ctx := context.Background()
+	tokens, err := m.IssueTokens(ctx, user, Client{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session, err := m.AuthenticateBearer(ctx, tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.UserID != user.ID || session.Role != "member" {
+		t.Fatalf("got session of %s with role %q", session.UserID.Hex(), session.Role)
+	}
+
+	// role changes apply to tokens already handed out
+	sessions.setRole(session.ID, "admin")
+	if session, err = m.AuthenticateBearer(ctx, tokens.AccessToken); err != nil || session.Role != "admin" {
+		t.Fatalf("after promotion: got role %q, %v", session.Role, err)
+	}
+
+	if err := sessions.RevokeByID(ctx, user.ID, session.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AuthenticateBearer(ctx, tokens.AccessToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("revoked session: got %v, want ErrSessionRevoked", err)
+	}
+}
+
+func TestAuthenticateBearerRejectsUnknownSession(t *testing.T) {
+	m, _, user := testManager(t)
+	token, err := m.Keys.SignJWT(Claims{
+		Issuer:    issuer,
+		Subject:   user.ID.Hex(),
+		Session:   primitive.NewObjectID().Hex(),
+		Use:       UseAccess,
+		Role:      "admin",
+		IssuedAt:  time.Now().Unix(),
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AuthenticateBearer(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("got %v, want ErrInvalidToken", err)
+	}
+}
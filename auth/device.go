@@ -0,0 +1,68 @@
+package auth
+
+import "strings"
+
+// Client describes where a login came from.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// maxUserAgent bounds the user agent stored with a session.
+const maxUserAgent = 512
+
+// browsers and platforms are checked in order; the first match wins, so
+// more specific tokens come first (Edge and Opera also claim Chrome,
+// Chrome also claims Safari, Android also claims Linux).
+var browsers = []struct{ token, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"CriOS/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"curl/", "curl"},
+}
+
+var platforms = []struct{ token, name string }{
+	{"Android", "Android"},
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Windows", "Windows"},
+	{"Mac OS X", "macOS"},
+	{"CrOS", "ChromeOS"},
+	{"Linux", "Linux"},
+}
+
+// DeviceName summarises a user agent for listing sessions, e.g.
+// "Firefox on Linux". It returns "" when nothing is recognised.
+func DeviceName(userAgent string) string {
+	browser, platform := "", ""
+	for _, b := range browsers {
+		if strings.Contains(userAgent, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	for _, p := range platforms {
+		if strings.Contains(userAgent, p.token) {
+			platform = p.name
+			break
+		}
+	}
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	default:
+		return platform
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	// UseAccess marks access tokens, sent as "Authorization: Bearer".
+	UseAccess = "access"
+	// UseRefresh marks refresh tokens, only accepted by POST /token.
+	UseRefresh = "refresh"
+
+	issuer = "aastu-cpc"
+)
+
+// Claims is the payload of the JWTs handed to API clients.
+type Claims struct {
+	Issuer  string `json:"iss"`
+	Subject string `json:"sub"` // user id
+	Session string `json:"sid"` // id of the session the token belongs to
+	ID      string `json:"jti,omitempty"`
+	Use     string `json:"use"`
+	Role    string `json:"role,omitempty"`
+	// IssuedAt and ExpiresAt are Unix times.
+	IssuedAt  int64 `json:"iat"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+type jwtHeader struct {
+	Alg   string `json:"alg"`
+	Type  string `json:"typ"`
+	KeyID string `json:"kid"`
+}
+
+// SignJWT returns claims as an HS256 JWT signed with the current key.
+func (k *Keyring) SignJWT(claims Claims) (string, error) {
+	key := k.keys[0]
+	header, err := json.Marshal(jwtHeader{Alg: "HS256", Type: "JWT", KeyID: key.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + sign(key.Secret, signed), nil
+}
+
+// ParseJWT checks a JWT's signature, issuer, use and expiry and returns its
+// claims. Only HS256 tokens signed by a key of the ring are accepted.
+func (k *Keyring) ParseJWT(token, use string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+	key, ok := k.key(header.KeyID)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(sign(key.Secret, parts[0]+"."+parts[1]))) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != issuer || claims.Use != use {
+		return nil, ErrInvalidToken
+	}
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+	return &claims, nil
+}
+
+func (k *Keyring) key(id string) (Key, bool) {
+	for _, key := range k.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
@@ -0,0 +1,131 @@
+// Package auth issues and checks login sessions.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed or not signed
+// by any configured key.
+var ErrInvalidToken = errors.New("invalid token")
+
+// Key is a named HMAC secret.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Keyring signs tokens with its first key and accepts tokens signed by any
+// of its keys, so keys can be rotated without logging everybody out: add
+// the new key in front, and drop the old one once its tokens have expired.
+type Keyring struct {
+	keys []Key
+}
+
+// NewKeyring creates a keyring; keys[0] signs new tokens.
+func NewKeyring(keys ...Key) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("auth: no signing keys")
+	}
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		if k.ID == "" || strings.Contains(k.ID, ".") {
+			return nil, fmt.Errorf("auth: invalid key id %q", k.ID)
+		}
+		if len(k.Secret) < 32 {
+			return nil, fmt.Errorf("auth: key %q is shorter than 32 bytes", k.ID)
+		}
+		if seen[k.ID] {
+			return nil, fmt.Errorf("auth: duplicate key id %q", k.ID)
+		}
+		seen[k.ID] = true
+	}
+	return &Keyring{keys: keys}, nil
+}
+
+// ParseKeys parses a config value of comma separated "id:secret" pairs,
+// where secret is base64 encoded, e.g. "2024b:...,2024a:...".
+func ParseKeys(config string) (*Keyring, error) {
+	var keys []Key
+	for _, part := range strings.Split(config, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("auth: key %q is not id:secret", part)
+		}
+		secret, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("auth: key %q: %w", id, err)
+		}
+		keys = append(keys, Key{ID: id, Secret: secret})
+	}
+	return NewKeyring(keys...)
+}
+
+// RandomKeyring returns a keyring with a single random key, for
+// development setups without configured keys. Tokens don't survive a
+// restart.
+func RandomKeyring() *Keyring {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return &Keyring{keys: []Key{{ID: "dev", Secret: secret}}}
+}
+
+// NewToken returns a fresh signed opaque token and the id under which its
+// session is stored. Only a hash of the token's secret part is stored, so
+// a leaked sessions collection can't be replayed.
+func (k *Keyring) NewToken() (token string, id string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(raw)
+	key := k.keys[0]
+	payload := key.ID + "." + secret
+	return payload + "." + sign(key.Secret, payload), hashSecret(secret), nil
+}
+
+// Verify checks a token's signature and returns its session id.
+func (k *Keyring) Verify(token string) (string, error) {
+	keyID, rest, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", ErrInvalidToken
+	}
+	secret, mac, ok := strings.Cut(rest, ".")
+	if !ok || secret == "" {
+		return "", ErrInvalidToken
+	}
+	for _, key := range k.keys {
+		if key.ID != keyID {
+			continue
+		}
+		if !hmac.Equal([]byte(mac), []byte(sign(key.Secret, keyID+"."+secret))) {
+			return "", ErrInvalidToken
+		}
+		return hashSecret(secret), nil
+	}
+	return "", ErrInvalidToken
+}
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testKey(id string, fill byte) Key {
+	return Key{ID: id, Secret: bytes.Repeat([]byte{fill}, 32)}
+}
+
+func mustKeyring(t *testing.T, keys ...Key) *Keyring {
+	t.Helper()
+	k, err := NewKeyring(keys...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func accessClaims() Claims {
+	now := time.Now()
+	return Claims{
+		Issuer:    issuer,
+		Subject:   "user",
+		Session:   "session",
+		Use:       UseAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(time.Minute).Unix(),
+	}
+}
+
+func TestKeyringRotation(t *testing.T) {
+	old := mustKeyring(t, testKey("a", 1))
+	rotated := mustKeyring(t, testKey("b", 2), testKey("a", 1))
+	retired := mustKeyring(t, testKey("b", 2))
+
+	token, id, err := old.NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt, err := old.SignJWT(accessClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := rotated.Verify(token); err != nil || got != id {
+		t.Errorf("rotated ring on old token: got %q, %v", got, err)
+	}
+	if _, err := rotated.ParseJWT(jwt, UseAccess); err != nil {
+		t.Errorf("rotated ring on old JWT: %v", err)
+	}
+	if _, err := retired.Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("retired key accepted for token: %v", err)
+	}
+	if _, err := retired.ParseJWT(jwt, UseAccess); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("retired key accepted for JWT: %v", err)
+	}
+
+	// new tokens are signed with the first key
+	token, _, err = rotated.NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, "b.") {
+		t.Errorf("token %q not signed with the new key", token)
+	}
+	if _, err := retired.Verify(token); err != nil {
+		t.Errorf("new token rejected once the old key is dropped: %v", err)
+	}
+}
+
+func TestNewKeyringRejects(t *testing.T) {
+	bad := map[string][]Key{
+		"no keys":      nil,
+		"short secret": {{ID: "a", Secret: []byte("short")}},
+		"empty id":     {testKey("", 1)},
+		"dot in id":    {testKey("a.b", 1)},
+		"duplicate id": {testKey("a", 1), testKey("a", 2)},
+	}
+	for name, keys := range bad {
+		if _, err := NewKeyring(keys...); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+// forge builds a JWT with the given header, signed with secret unless it
+// is nil.
+func forge(header string, claims Claims, secret []byte) string {
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	if secret == nil {
+		return signed + "."
+	}
+	return signed + "." + sign(secret, signed)
+}
+
+func TestParseJWTRejects(t *testing.T) {
+	key := testKey("a", 1)
+	k := mustKeyring(t, key)
+
+	expired := accessClaims()
+	expired.ExpiresAt = time.Now().Add(-time.Second).Unix()
+	otherIssuer := accessClaims()
+	otherIssuer.Issuer = "someone-else"
+	valid, err := k.SignJWT(accessClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+
+	tests := map[string]struct {
+		token string
+		use   string
+		want  error
+	}{
+		"alg none":       {forge(`{"alg":"none","typ":"JWT","kid":"a"}`, accessClaims(), nil), UseAccess, ErrInvalidToken},
+		"alg RS256":      {forge(`{"alg":"RS256","typ":"JWT","kid":"a"}`, accessClaims(), key.Secret), UseAccess, ErrInvalidToken},
+		"unknown kid":    {forge(`{"alg":"HS256","typ":"JWT","kid":"z"}`, accessClaims(), key.Secret), UseAccess, ErrInvalidToken},
+		"no kid":         {forge(`{"alg":"HS256","typ":"JWT"}`, accessClaims(), key.Secret), UseAccess, ErrInvalidToken},
+		"other secret":   {forge(`{"alg":"HS256","typ":"JWT","kid":"a"}`, accessClaims(), bytes.Repeat([]byte{2}, 32)), UseAccess, ErrInvalidToken},
+		"tampered":       {parts[0] + "." + parts[1] + "x." + parts[2], UseAccess, ErrInvalidToken},
+		"refresh as use": {valid, UseRefresh, ErrInvalidToken},
+		"other issuer":   {forge(`{"alg":"HS256","typ":"JWT","kid":"a"}`, otherIssuer, key.Secret), UseAccess, ErrInvalidToken},
+		"expired":        {forge(`{"alg":"HS256","typ":"JWT","kid":"a"}`, expired, key.Secret), UseAccess, ErrTokenExpired},
+		"two segments":   {parts[0] + "." + parts[1], UseAccess, ErrInvalidToken},
+	}
+	for name, tt := range tests {
+		if _, err := k.ParseJWT(tt.token, tt.use); !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", name, err, tt.want)
+		}
+	}
+	if _, err := k.ParseJWT(valid, UseAccess); err != nil {
+		t.Errorf("valid token: %v", err)
+	}
+}
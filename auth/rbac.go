@@ -0,0 +1,92 @@
+package auth
+
+// Permission is the right to perform one kind of action.
+type Permission string
+
+const (
+	// PermProblemCreate allows creating problems and editing, deleting and
+	// managing the tests of one's own.
+	PermProblemCreate Permission = "problem:create"
+	// PermProblemManage allows editing and deleting any problem.
+	PermProblemManage Permission = "problem:manage"
+	// PermArticleCreate allows writing articles and editing and deleting
+	// one's own. Without PermArticlePublish they stay drafts.
+	PermArticleCreate Permission = "article:create"
+	// PermArticlePublish allows making articles public.
+	PermArticlePublish Permission = "article:publish"
+	// PermArticleManage allows editing and deleting any article.
+	PermArticleManage Permission = "article:manage"
+	// PermProblemSetManage allows creating, editing and deleting problem sets.
+	PermProblemSetManage Permission = "problemset:manage"
+	// PermUserManage allows creating, editing and deleting member, setter
+	// and mentor accounts.
+	PermUserManage Permission = "user:manage"
+	// PermRoleManage allows granting and revoking the admin and root roles
+	// and managing admin and root accounts.
+	PermRoleManage Permission = "role:manage"
+	// PermImportRun allows importing problems from external archives.
+	PermImportRun Permission = "import:run"
+	// PermStatsView allows viewing operational statistics.
+	PermStatsView Permission = "stats:view"
+)
+
+// Roles, from least to most privileged. Users without a known role are
+// members.
+const (
+	RoleMember = "member"
+	RoleSetter = "setter"
+	RoleMentor = "mentor"
+	RoleAdmin  = "admin"
+	RoleRoot   = "root"
+)
+
+// Each role has the permissions of the one before it and more.
+var (
+	setterPermissions = []Permission{PermProblemCreate, PermArticleCreate}
+	mentorPermissions = with(setterPermissions, PermArticlePublish, PermProblemSetManage)
+	adminPermissions  = with(mentorPermissions,
+		PermProblemManage, PermArticleManage, PermUserManage, PermImportRun, PermStatsView)
+	rootPermissions = with(adminPermissions, PermRoleManage)
+)
+
+func with(base []Permission, more ...Permission) []Permission {
+	return append(append([]Permission(nil), base...), more...)
+}
+
+var rolePermissions = map[string][]Permission{
+	RoleMember: nil,
+	RoleSetter: setterPermissions,
+	RoleMentor: mentorPermissions,
+	RoleAdmin:  adminPermissions,
+	RoleRoot:   rootPermissions,
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// Permissions returns the permissions granted to role.
+func Permissions(role string) []Permission {
+	return rolePermissions[role]
+}
+
+// Can reports whether role grants every one of perms.
+func Can(role string, perms ...Permission) bool {
+	for _, p := range perms {
+		if !grants(role, p) {
+			return false
+		}
+	}
+	return true
+}
+
+func grants(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrNoSession is returned when a request carries no session token.
+	ErrNoSession = errors.New("not logged in")
+	// ErrSessionExpired is returned for sessions past their expiry.
+	ErrSessionExpired = errors.New("session expired")
+	// ErrSessionRevoked is returned for sessions ended by logging out.
+	ErrSessionRevoked = errors.New("session revoked")
+)
+
+// Config controls session lifetime and the session cookie.
+type Config struct {
+	// TTL is how long a session lives without being used.
+	TTL time.Duration
+	// MaxAge bounds a session's life however actively it is used.
+	MaxAge time.Duration
+	// RenewAfter is how stale the last renewal may get before a request
+	// slides the expiry forward; it keeps most requests read-only.
+	RenewAfter time.Duration
+	CookieName string
+	// AccessTTL is the lifetime of bearer access tokens. Refresh tokens
+	// live as long as their session.
+	AccessTTL time.Duration
+	// Secure limits the cookie to HTTPS; turn it off only for local
+	// development over plain HTTP.
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// DefaultConfig returns the settings used unless overridden.
+func DefaultConfig() Config {
+	return Config{
+		TTL:        7 * 24 * time.Hour,
+		MaxAge:     30 * 24 * time.Hour,
+		RenewAfter: time.Hour,
+		CookieName: "aastu_session",
+		AccessTTL:  15 * time.Minute,
+		Secure:     true,
+		SameSite:   http.SameSiteLaxMode,
+	}
+}
+
+// SessionStore keeps sessions; it is implemented by
+// repository.SessionRepository.
+type SessionStore interface {
+	Create(ctx context.Context, session *models.Session) error
+	GetBySessionID(ctx context.Context, sessionID string) (*models.Session, error)
+	GetByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error)
+	Touch(ctx context.Context, sessionID string, seen time.Time, expires time.Time) error
+	Rotate(ctx context.Context, id primitive.ObjectID, old string, session *models.Session) error
+	Revoke(ctx context.Context, sessionID string) error
+	RevokeByID(ctx context.Context, userID, id primitive.ObjectID) error
+}
+
+// UserStore looks up the users sessions belong to; it is implemented by
+// repository.UserRepository.
+type UserStore interface {
+	GetByID(ctx context.Context, id string) (*models.User, error)
+}
+
+var (
+	_ SessionStore = (*repository.SessionRepository)(nil)
+	_ UserStore    = (*repository.UserRepository)(nil)
+)
+
+// Manager issues, checks and ends sessions.
+type Manager struct {
+	Keys   *Keyring
+	Repo   SessionStore
+	Users  UserStore
+	Config Config
+}
+
+// NewManager creates a session manager.
+func NewManager(keys *Keyring, repo SessionStore, users UserStore, config Config) *Manager {
+	return &Manager{Keys: keys, Repo: repo, Users: users, Config: config}
+}
+
+// Issue starts a session for user on the client and returns the token to
+// hand out. Earlier sessions of the user, on other devices, stay valid.
+func (m *Manager) Issue(ctx context.Context, user models.User, client Client) (string, *models.Session, error) {
+	token, id, err := m.Keys.NewToken()
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	session := &models.Session{
+		SessionID:  id,
+		UserID:     user.ID,
+		Role:       user.Role,
+		Issued:     now,
+		LastSeenAt: now,
+		ExpiresAt:  m.expiry(now, now),
+		Device:     DeviceName(client.UserAgent),
+		UserAgent:  truncate(client.UserAgent, maxUserAgent),
+		IP:         client.IP,
+	}
+	if err := m.Repo.Create(ctx, session); err != nil {
+		return "", nil, err
+	}
+	return token, session, nil
+}
+
+// Validate returns the live session for token. If the session was due for
+// renewal its expiry is moved and renewed is true.
+func (m *Manager) Validate(ctx context.Context, token string) (session *models.Session, renewed bool, err error) {
+	id, err := m.Keys.Verify(token)
+	if err != nil {
+		return nil, false, err
+	}
+	session, err = m.Repo.GetBySessionID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	now := time.Now()
+	if !session.RevokedAt.IsZero() {
+		return nil, false, ErrSessionRevoked
+	}
+	if !now.Before(session.ExpiresAt) {
+		return nil, false, ErrSessionExpired
+	}
+
+	if now.Sub(session.LastSeenAt) >= m.Config.RenewAfter {
+		expires := m.expiry(session.Issued, now)
+		if err := m.Repo.Touch(ctx, id, now, expires); err != nil {
+			return nil, false, err
+		}
+		session.LastSeenAt, session.ExpiresAt = now, expires
+		renewed = true
+	}
+	return session, renewed, nil
+}
+
+// Revoke ends the session of token.
+func (m *Manager) Revoke(ctx context.Context, token string) error {
+	id, err := m.Keys.Verify(token)
+	if err != nil {
+		return err
+	}
+	return m.Repo.Revoke(ctx, id)
+}
+
+// expiry is the sliding expiry of a session used at now, capped by MaxAge.
+func (m *Manager) expiry(issued, now time.Time) time.Time {
+	expires := now.Add(m.Config.TTL)
+	if limit := issued.Add(m.Config.MaxAge); m.Config.MaxAge > 0 && expires.After(limit) {
+		expires = limit
+	}
+	return expires
+}
+
+// Token returns the session token of a request, or "".
+func (m *Manager) Token(r *http.Request) string {
+	cookie, err := r.Cookie(m.Config.CookieName)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// Authenticate validates the session of a request, refreshing the cookie
+// when the session was renewed.
+func (m *Manager) Authenticate(w http.ResponseWriter, r *http.Request) (*models.Session, error) {
+	token := m.Token(r)
+	if token == "" {
+		return nil, ErrNoSession
+	}
+	session, renewed, err := m.Validate(r.Context(), token)
+	if err != nil {
+		return nil, err
+	}
+	if renewed {
+		m.SetCookie(w, token, session.ExpiresAt)
+	}
+	return session, nil
+}
+
+// SetCookie hands token to the browser until expires.
+func (m *Manager) SetCookie(w http.ResponseWriter, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.Config.CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		MaxAge:   int(time.Until(expires).Seconds()),
+		HttpOnly: true,
+		Secure:   m.Config.Secure,
+		SameSite: m.Config.SameSite,
+	})
+}
+
+// ClearCookie removes the session cookie from the browser.
+func (m *Manager) ClearCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     m.Config.CookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   m.Config.Secure,
+		SameSite: m.Config.SameSite,
+	})
+}
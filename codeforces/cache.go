@@ -0,0 +1,75 @@
+package codeforces
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// cachedMethods are the methods whose results are cached. Both are hit once
+// per validation and change slowly enough that a short TTL is harmless.
+var cachedMethods = map[string]bool{
+	"contest.status": true,
+	"user.status":    true,
+}
+
+type freshKey struct{}
+
+// Fresh returns a context under which calls skip the cache and always ask
+// Codeforces, for checks that must see submissions made seconds ago. The
+// fresh results are still cached for other callers.
+func Fresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshKey{}, true)
+}
+
+func isFresh(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshKey{}).(bool)
+	return fresh
+}
+
+type cacheEntry struct {
+	result  json.RawMessage
+	expires time.Time
+}
+
+// responseCache keeps raw API results for a fixed TTL.
+type responseCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]cacheEntry
+	lastSweep time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{
+		ttl:       ttl,
+		entries:   make(map[string]cacheEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+func (c *responseCache) get(key string) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (c *responseCache) set(key string, result json.RawMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.Sub(c.lastSweep) > c.ttl {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		c.lastSweep = now
+	}
+	c.entries[key] = cacheEntry{result: result, expires: now.Add(c.ttl)}
+}
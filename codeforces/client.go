@@ -0,0 +1,247 @@
+package codeforces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the public Codeforces API endpoint.
+const DefaultBaseURL = "https://codeforces.com/api"
+
+// DefaultTimeout bounds a single API call when no timeout is configured.
+const DefaultTimeout = 15 * time.Second
+
+// DefaultInterval is the spacing between calls Codeforces tolerates.
+const DefaultInterval = 2 * time.Second
+
+// DefaultCacheTTL is how long contest.status and user.status results are reused.
+const DefaultCacheTTL = 30 * time.Second
+
+// APIError is returned when Codeforces answers with status FAILED.
+type APIError struct {
+	Method  string
+	Comment string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("codeforces %s: %s", e.Method, e.Comment)
+}
+
+// Client talks to the Codeforces API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+	apiSecret  string
+	now        func() time.Time
+	limiter    *Limiter
+	cache      *responseCache
+	counters   counters
+}
+
+// NewClient creates a client for the API at baseURL. An empty baseURL means
+// DefaultBaseURL and a non-positive timeout means DefaultTimeout. The client
+// is rate limited to one call per DefaultInterval and caches results for
+// DefaultCacheTTL; share one client across the process so the limit is global.
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+		now:        time.Now,
+		limiter:    NewLimiter(DefaultInterval, 1),
+		cache:      newResponseCache(DefaultCacheTTL),
+	}
+}
+
+// WithRateLimit replaces the limiter with one allowing a call per interval
+// and bursts of burst calls.
+func (c *Client) WithRateLimit(interval time.Duration, burst int) *Client {
+	c.limiter = NewLimiter(interval, burst)
+	return c
+}
+
+// WithCacheTTL changes how long results are cached; zero disables caching.
+func (c *Client) WithCacheTTL(ttl time.Duration) *Client {
+	if ttl <= 0 {
+		c.cache = nil
+	} else {
+		c.cache = newResponseCache(ttl)
+	}
+	return c
+}
+
+type response struct {
+	Status  string          `json:"status"`
+	Comment string          `json:"comment"`
+	Result  json.RawMessage `json:"result"`
+}
+
+// call performs a GET on method and decodes the result field into result.
+func (c *Client) call(ctx context.Context, method string, params url.Values, result any) error {
+	cacheKey := ""
+	if c.cache != nil && cachedMethods[method] {
+		cacheKey = method + "?" + params.Encode()
+		if raw, ok := c.cache.get(cacheKey); ok && !isFresh(ctx) {
+			c.counters.cacheHits.Add(1)
+			return c.decodeResult(method, raw, result)
+		}
+		c.counters.cacheMisses.Add(1)
+	}
+
+	raw, err := c.fetch(ctx, method, params)
+	if err != nil {
+		c.counters.failures.Add(1)
+		return err
+	}
+	if cacheKey != "" {
+		c.cache.set(cacheKey, raw)
+	}
+	return c.decodeResult(method, raw, result)
+}
+
+func (c *Client) decodeResult(method string, raw json.RawMessage, result any) error {
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("codeforces %s: decoding result: %w", method, err)
+	}
+	return nil
+}
+
+// fetch waits for the limiter and returns the raw result of method.
+func (c *Client) fetch(ctx context.Context, method string, params url.Values) (json.RawMessage, error) {
+	waited, err := c.limiter.Wait(ctx)
+	if waited > 0 {
+		c.counters.limiterWaits.Add(1)
+		c.counters.limiterWait.Add(int64(waited))
+		log.Printf("codeforces: %s waited %s for the rate limiter", method, waited.Round(time.Millisecond))
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.counters.requests.Add(1)
+
+	if c.signing() {
+		if params, err = c.sign(method, params); err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint := c.baseURL + "/" + method
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("codeforces %s: %w", method, err)
+	}
+	defer res.Body.Close()
+
+	var body response
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("codeforces %s: unexpected response (HTTP %d): %w", method, res.StatusCode, err)
+	}
+	if body.Status != "OK" {
+		return nil, &APIError{Method: method, Comment: body.Comment}
+	}
+	return body.Result, nil
+}
+
+// pageParams adds the optional from/count paging parameters.
+func pageParams(params url.Values, from, count int) {
+	if from > 0 {
+		params.Set("from", strconv.Itoa(from))
+	}
+	if count > 0 {
+		params.Set("count", strconv.Itoa(count))
+	}
+}
+
+// ContestStatus returns submissions to a contest, optionally only those of
+// handle. from and count page through the results; zero means unset.
+func (c *Client) ContestStatus(ctx context.Context, contestID int, handle string, from, count int) ([]Submission, error) {
+	params := url.Values{"contestId": {strconv.Itoa(contestID)}}
+	if handle != "" {
+		params.Set("handle", handle)
+	}
+	pageParams(params, from, count)
+
+	var submissions []Submission
+	err := c.call(ctx, "contest.status", params, &submissions)
+	return submissions, err
+}
+
+// UserStatus returns the submissions of handle, newest first.
+func (c *Client) UserStatus(ctx context.Context, handle string, from, count int) ([]Submission, error) {
+	params := url.Values{"handle": {handle}}
+	pageParams(params, from, count)
+
+	var submissions []Submission
+	err := c.call(ctx, "user.status", params, &submissions)
+	return submissions, err
+}
+
+// UserInfo returns the accounts of the given handles.
+func (c *Client) UserInfo(ctx context.Context, handles ...string) ([]User, error) {
+	params := url.Values{"handles": {strings.Join(handles, ";")}}
+
+	var users []User
+	err := c.call(ctx, "user.info", params, &users)
+	return users, err
+}
+
+// UserRating returns the rating history of handle.
+func (c *Client) UserRating(ctx context.Context, handle string) ([]RatingChange, error) {
+	params := url.Values{"handle": {handle}}
+
+	var changes []RatingChange
+	err := c.call(ctx, "user.rating", params, &changes)
+	return changes, err
+}
+
+// ProblemsetProblems returns the problemset, optionally restricted to
+// problems having all of tags.
+func (c *Client) ProblemsetProblems(ctx context.Context, tags ...string) (*Problemset, error) {
+	params := url.Values{}
+	if len(tags) > 0 {
+		params.Set("tags", strings.Join(tags, ";"))
+	}
+
+	var problemset Problemset
+	if err := c.call(ctx, "problemset.problems", params, &problemset); err != nil {
+		return nil, err
+	}
+	return &problemset, nil
+}
+
+// ContestStandings returns the standings of a contest. from and count page
+// through the rows; zero means unset.
+func (c *Client) ContestStandings(ctx context.Context, contestID int, from, count int, showUnofficial bool) (*Standings, error) {
+	params := url.Values{"contestId": {strconv.Itoa(contestID)}}
+	pageParams(params, from, count)
+	if showUnofficial {
+		params.Set("showUnofficial", "true")
+	}
+
+	var standings Standings
+	if err := c.call(ctx, "contest.standings", params, &standings); err != nil {
+		return nil, err
+	}
+	return &standings, nil
+}
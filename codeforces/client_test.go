@@ -0,0 +1,180 @@
+package codeforces
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer answers every request with body; it counts the requests and
+// keeps the query of the last one.
+func testServer(t *testing.T, body string) (*Client, *atomic.Int32, *url.Values) {
+	t.Helper()
+	var calls atomic.Int32
+	var last url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		last = r.URL.Query()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	c := NewClient(srv.URL, time.Second).WithRateLimit(time.Millisecond, 100)
+	return c, &calls, &last
+}
+
+const userStatusOK = `{"status":"OK","result":[
+{"id":2,"contestId":4,"creationTimeSeconds":1700000000,"problem":{"contestId":4,"index":"A","name":"Watermelon"},"verdict":"OK"}
+]}`
+
+func TestUserStatus(t *testing.T) {
+	c, _, query := testServer(t, userStatusOK)
+	submissions, err := c.UserStatus(context.Background(), "tourist", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != 1 || submissions[0].ID != 2 || submissions[0].Problem.Index != "A" || submissions[0].Verdict != "OK" {
+		t.Errorf("got %+v", submissions)
+	}
+	if q := *query; q.Get("handle") != "tourist" || q.Get("from") != "1" || q.Get("count") != "10" {
+		t.Errorf("query = %v", q)
+	}
+}
+
+func TestFailedIsAPIError(t *testing.T) {
+	c, _, _ := testServer(t, `{"status":"FAILED","comment":"handle: User with handle nobody not found"}`)
+	_, err := c.UserInfo(context.Background(), "nobody")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want an APIError", err)
+	}
+	if apiErr.Method != "user.info" || apiErr.Comment != "handle: User with handle nobody not found" {
+		t.Errorf("got %+v", apiErr)
+	}
+}
+
+func TestMalformedResponse(t *testing.T) {
+	c, _, _ := testServer(t, `<html>Codeforces is temporarily unavailable</html>`)
+	_, err := c.UserStatus(context.Background(), "tourist", 0, 0)
+	var apiErr *APIError
+	if err == nil || errors.As(err, &apiErr) {
+		t.Errorf("got %v, want a decoding error", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	c, calls, _ := testServer(t, userStatusOK)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.UserStatus(ctx, "tourist", 0, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("cached method made %d requests, want 1", n)
+	}
+
+	// other parameters are another entry
+	if _, err := c.UserStatus(ctx, "petr", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("other handle made %d requests in total, want 2", n)
+	}
+
+	// Fresh skips the cache but refills it
+	if _, err := c.UserStatus(Fresh(ctx), "tourist", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("fresh call made %d requests in total, want 3", n)
+	}
+
+	// uncached methods always go out
+	for i := 0; i < 2; i++ {
+		c.UserRating(ctx, "tourist")
+	}
+	if n := calls.Load(); n != 5 {
+		t.Errorf("uncached method made %d requests in total, want 5", n)
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	c, calls, _ := testServer(t, userStatusOK)
+	c.WithCacheTTL(0)
+	for i := 0; i < 2; i++ {
+		if _, err := c.UserStatus(context.Background(), "tourist", 0, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
+
+func TestFailuresAreNotCached(t *testing.T) {
+	c, calls, _ := testServer(t, `{"status":"FAILED","comment":"Call limit exceeded"}`)
+	for i := 0; i < 2; i++ {
+		c.UserStatus(context.Background(), "tourist", 0, 0)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("made %d requests, want 2", n)
+	}
+}
+
+func TestSigning(t *testing.T) {
+	c, _, query := testServer(t, `{"status":"OK","result":[]}`)
+	c.WithCredentials("key", "secret")
+	c.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	if _, err := c.ContestStatus(context.Background(), 566, "", 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	q := *query
+	if q.Get("apiKey") != "key" || q.Get("time") != "1700000000" {
+		t.Fatalf("query = %v", q)
+	}
+
+	// apiSig is a 6 character prefix and the SHA-512 of
+	// prefix/method?sorted params#secret
+	sig := q.Get("apiSig")
+	if len(sig) != 6+128 {
+		t.Fatalf("apiSig %q has the wrong length", sig)
+	}
+	prefix := sig[:6]
+	sum := sha512.Sum512([]byte(prefix + "/contest.status?apiKey=key&contestId=566&count=1&from=1&time=1700000000#secret"))
+	if want := prefix + hex.EncodeToString(sum[:]); sig != want {
+		t.Errorf("apiSig = %s, want %s", sig, want)
+	}
+}
+
+func TestUnsignedWithoutCredentials(t *testing.T) {
+	c, _, query := testServer(t, `{"status":"OK","result":[]}`)
+	if _, err := c.ContestStatus(context.Background(), 566, "", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if q := *query; q.Has("apiSig") || q.Has("apiKey") {
+		t.Errorf("query = %v", q)
+	}
+}
+
+func TestLimiterZeroInterval(t *testing.T) {
+	l := NewLimiter(0, 1)
+	if _, err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// a zero interval falls back to the default instead of never refilling
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second call did not wait: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package codeforces
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket shared by every call made through a Client.
+// Codeforces answers "Call limit exceeded" to clients making more than about
+// one request every two seconds.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    float64
+	tokens   float64
+	last     time.Time
+}
+
+// NewLimiter allows one call per interval with bursts of up to burst calls.
+// A non-positive interval means DefaultInterval.
+func NewLimiter(interval time.Duration, burst int) *Limiter {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		interval: interval,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a call may be made and returns how long it waited.
+func (l *Limiter) Wait(ctx context.Context) (time.Duration, error) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	// Take the token now, possibly going into debt, so that concurrent
+	// callers queue up behind each other instead of all waking at once.
+	l.tokens--
+	if l.tokens >= 0 {
+		l.mu.Unlock()
+		return 0, nil
+	}
+	wait := time.Duration(-l.tokens * float64(l.interval))
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return wait, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return time.Since(now), ctx.Err()
+	}
+}
@@ -0,0 +1,37 @@
+package codeforces
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats counts what the client did since it was created.
+type Stats struct {
+	Requests     int64         `json:"requests"`
+	Failures     int64         `json:"failures"`
+	CacheHits    int64         `json:"cache_hits"`
+	CacheMisses  int64         `json:"cache_misses"`
+	LimiterWaits int64         `json:"limiter_waits"`
+	LimiterWait  time.Duration `json:"limiter_wait_ns"`
+}
+
+type counters struct {
+	requests     atomic.Int64
+	failures     atomic.Int64
+	cacheHits    atomic.Int64
+	cacheMisses  atomic.Int64
+	limiterWaits atomic.Int64
+	limiterWait  atomic.Int64
+}
+
+// Stats returns a snapshot of the client's counters.
+func (c *Client) Stats() Stats {
+	return Stats{
+		Requests:     c.counters.requests.Load(),
+		Failures:     c.counters.failures.Load(),
+		CacheHits:    c.counters.cacheHits.Load(),
+		CacheMisses:  c.counters.cacheMisses.Load(),
+		LimiterWaits: c.counters.limiterWaits.Load(),
+		LimiterWait:  time.Duration(c.counters.limiterWait.Load()),
+	}
+}
@@ -0,0 +1,132 @@
+package codeforces
+
+// Problem is a problem as returned by the Codeforces API.
+type Problem struct {
+	ContestID      int      `json:"contestId"`
+	ProblemsetName string   `json:"problemsetName"`
+	Index          string   `json:"index"`
+	Name           string   `json:"name"`
+	Type           string   `json:"type"`
+	Points         float64  `json:"points"`
+	Rating         int      `json:"rating"`
+	Tags           []string `json:"tags"`
+}
+
+// Member is a single user inside a Party.
+type Member struct {
+	Handle string `json:"handle"`
+	Name   string `json:"name"`
+}
+
+// Party is the author of a submission or a row of the standings. Teams have
+// more than one member.
+type Party struct {
+	ContestID        int      `json:"contestId"`
+	Members          []Member `json:"members"`
+	ParticipantType  string   `json:"participantType"`
+	TeamID           int      `json:"teamId"`
+	TeamName         string   `json:"teamName"`
+	Ghost            bool     `json:"ghost"`
+	Room             int      `json:"room"`
+	StartTimeSeconds int64    `json:"startTimeSeconds"`
+}
+
+// Submission is a single submission result.
+type Submission struct {
+	ID                  int64   `json:"id"`
+	ContestID           int     `json:"contestId"`
+	CreationTimeSeconds int64   `json:"creationTimeSeconds"`
+	RelativeTimeSeconds int64   `json:"relativeTimeSeconds"`
+	Problem             Problem `json:"problem"`
+	Author              Party   `json:"author"`
+	ProgrammingLanguage string  `json:"programmingLanguage"`
+	Verdict             string  `json:"verdict"`
+	Testset             string  `json:"testset"`
+	PassedTestCount     int     `json:"passedTestCount"`
+	TimeConsumedMillis  int     `json:"timeConsumedMillis"`
+	MemoryConsumedBytes int64   `json:"memoryConsumedBytes"`
+}
+
+// User is a Codeforces account as returned by user.info.
+type User struct {
+	Handle                  string `json:"handle"`
+	Email                   string `json:"email"`
+	FirstName               string `json:"firstName"`
+	LastName                string `json:"lastName"`
+	Country                 string `json:"country"`
+	City                    string `json:"city"`
+	Organization            string `json:"organization"`
+	Contribution            int    `json:"contribution"`
+	Rank                    string `json:"rank"`
+	Rating                  int    `json:"rating"`
+	MaxRank                 string `json:"maxRank"`
+	MaxRating               int    `json:"maxRating"`
+	LastOnlineTimeSeconds   int64  `json:"lastOnlineTimeSeconds"`
+	RegistrationTimeSeconds int64  `json:"registrationTimeSeconds"`
+	Avatar                  string `json:"avatar"`
+	TitlePhoto              string `json:"titlePhoto"`
+}
+
+// RatingChange is one entry of a user's rating history.
+type RatingChange struct {
+	ContestID               int    `json:"contestId"`
+	ContestName             string `json:"contestName"`
+	Handle                  string `json:"handle"`
+	Rank                    int    `json:"rank"`
+	RatingUpdateTimeSeconds int64  `json:"ratingUpdateTimeSeconds"`
+	OldRating               int    `json:"oldRating"`
+	NewRating               int    `json:"newRating"`
+}
+
+// ProblemStatistics holds the solve count of a problem.
+type ProblemStatistics struct {
+	ContestID   int    `json:"contestId"`
+	Index       string `json:"index"`
+	SolvedCount int    `json:"solvedCount"`
+}
+
+// Problemset is the result of problemset.problems.
+type Problemset struct {
+	Problems          []Problem           `json:"problems"`
+	ProblemStatistics []ProblemStatistics `json:"problemStatistics"`
+}
+
+// Contest describes a contest or gym.
+type Contest struct {
+	ID                  int    `json:"id"`
+	Name                string `json:"name"`
+	Type                string `json:"type"`
+	Phase               string `json:"phase"`
+	Frozen              bool   `json:"frozen"`
+	DurationSeconds     int64  `json:"durationSeconds"`
+	StartTimeSeconds    int64  `json:"startTimeSeconds"`
+	RelativeTimeSeconds int64  `json:"relativeTimeSeconds"`
+	PreparedBy          string `json:"preparedBy"`
+	Kind                string `json:"kind"`
+	Season              string `json:"season"`
+}
+
+// ProblemResult is a party's result on one problem of the standings.
+type ProblemResult struct {
+	Points                    float64 `json:"points"`
+	Penalty                   int     `json:"penalty"`
+	RejectedAttemptCount      int     `json:"rejectedAttemptCount"`
+	Type                      string  `json:"type"`
+	BestSubmissionTimeSeconds int64   `json:"bestSubmissionTimeSeconds"`
+}
+
+// RanklistRow is one row of the standings.
+type RanklistRow struct {
+	Party          Party           `json:"party"`
+	Rank           int             `json:"rank"`
+	Points         float64         `json:"points"`
+	Penalty        int             `json:"penalty"`
+	ProblemResults []ProblemResult `json:"problemResults"`
+}
+
+// Standings is the result of contest.standings.
+type Standings struct {
+	Contest  Contest       `json:"contest"`
+	Problems []Problem     `json:"problems"`
+	Rows     []RanklistRow `json:"rows"`
+}
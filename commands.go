@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/AbenezerWork/AASTU-CPC/importer"
+	"github.com/AbenezerWork/AASTU-CPC/scoring"
+	"github.com/AbenezerWork/AASTU-CPC/worker"
+)
+
+// recomputeScores handles `recompute-scores`, rebuilding every user's score
+// from their accepted submissions.
+func recomputeScores(scorer *scoring.Engine) {
+	n, err := scorer.Recompute(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("recomputed scores from %d accepted submissions", n)
+}
+
+// importProblemset handles `import-problemset [-tags dp,greedy] [-min 800] [-max 1600]`.
+func importProblemset(cf *importer.Codeforces, args []string) {
+	fs := flag.NewFlagSet("import-problemset", flag.ExitOnError)
+	tags := fs.String("tags", "", "comma separated tags every problem must have")
+	minRating := fs.Int("min", 0, "minimum rating")
+	maxRating := fs.Int("max", 0, "maximum rating")
+	fs.Parse(args)
+
+	query := importer.ProblemsetQuery{MinRating: *minRating, MaxRating: *maxRating}
+	if *tags != "" {
+		query.Tags = strings.Split(*tags, ",")
+	}
+	result, err := cf.ImportProblemset(context.Background(), query)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("fetched %d problems, %d matched: %d inserted, %d updated", result.Fetched, result.Matched, result.Inserted, result.Updated)
+}
+
+// importContest handles `import-contest [-division div2] <contest id>`.
+func importContest(cf *importer.Codeforces, args []string) {
+	fs := flag.NewFlagSet("import-contest", flag.ExitOnError)
+	division := fs.String("division", "", "division the problem set is for")
+	fs.Parse(args)
+
+	contestID, err := strconv.Atoi(fs.Arg(0))
+	if err != nil {
+		log.Fatal("usage: import-contest [-division name] <contest id>")
+	}
+	set, result, err := cf.ImportContest(context.Background(), importer.ContestQuery{ContestID: contestID, Division: *division})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("imported %q as problem set %s: %d problems, %d inserted, %d updated", set.Title, set.ID.Hex(), len(set.ProblemIDs), result.Inserted, result.Updated)
+}
+
+// importCSES handles `import-cses`.
+func importCSES(cses *importer.CSES) {
+	result, err := cses.Import(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("fetched %d CSES tasks: %d inserted, %d updated", result.Fetched, result.Inserted, result.Updated)
+}
+
+// runJudgeWorker handles `judge-worker [-workers 2]`, judging native
+// submissions from the job queue until interrupted. Run it as root on
+// machines set aside for judging, with SANDBOX_USER and CHECKER_USER naming
+// the unprivileged users submissions and checkers run as.
+func runJudgeWorker(w *worker.JudgeWorker, args []string) {
+	fs := flag.NewFlagSet("judge-worker", flag.ExitOnError)
+	workers := fs.Int("workers", max(w.Workers, 1), "submissions judged concurrently")
+	fs.Parse(args)
+
+	requireIsolation(w)
+	w.Workers = *workers
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	log.Printf("judge worker %s running %d workers", w.ID, w.Workers)
+	w.Run(ctx)
+	log.Println("judge worker stopped")
+}
+
+// requireIsolation refuses to judge with submissions running as our own
+// user, able to read .env and our environment, unless JUDGE_INSECURE=true
+// says this is a development machine.
+func requireIsolation(w *worker.JudgeWorker) {
+	if w.Judge.Isolation != nil && w.Judge.Grader.Isolation != nil {
+		return
+	}
+	if os.Getenv("JUDGE_INSECURE") != "true" {
+		log.Fatal("judging needs SANDBOX_USER and CHECKER_USER; set JUDGE_INSECURE=true to run submissions unisolated on a development machine")
+	}
+	log.Println("judge: JUDGE_INSECURE is set, submissions run as this user with network access")
+}
@@ -0,0 +1,19 @@
+package controllers
+
+import (
+	"github.com/AbenezerWork/AASTU-CPC/auth"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// canModify reports whether the caller may change something owned by
+// owner: either their role grants any, or it grants own and they are the
+// owner. Things without an owner, like imported problems, need any.
+func canModify(c *gin.Context, owner primitive.ObjectID, own, any auth.Permission) bool {
+	session := c.MustGet("session").(models.Session)
+	if auth.Can(session.Role, any) {
+		return true
+	}
+	return owner != primitive.NilObjectID && owner == session.UserID && auth.Can(session.Role, own)
+}
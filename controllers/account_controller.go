@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/verifier"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AccountController links users' accounts on judges other than Codeforces,
+// whose verifiers prove ownership through a verifier.Challenger.
+type AccountController struct {
+	Verifiers *verifier.Registry
+	Userrepo  *repository.UserRepository
+}
+
+// NewAccountController initializes a new AccountController.
+func NewAccountController(vr *verifier.Registry, ur *repository.UserRepository) *AccountController {
+	return &AccountController{
+		Verifiers: vr,
+		Userrepo:  ur,
+	}
+}
+
+type accountChallengeRequest struct {
+	Method string `json:"method"`
+}
+
+// challenger returns the challenger of the judge named in the path.
+func (ctrl *AccountController) challenger(c *gin.Context) (verifier.Challenger, bool) {
+	v, err := ctrl.Verifiers.Get(c.Param("judge"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown judge"})
+		return nil, false
+	}
+	challenger, ok := v.(verifier.Challenger)
+	if !ok || c.Param("judge") == "codeforces" {
+		// Codeforces has its own endpoints under /me/codeforces
+		c.JSON(http.StatusNotFound, gin.H{"error": "Accounts on this judge are not verified here"})
+		return nil, false
+	}
+	return challenger, true
+}
+
+// IssueChallenge handles POST /me/accounts/:judge/challenge
+// @Summary Start judge account verification
+// @Description Issue a challenge proving the logged in user owns their handle on a judge. For AtCoder, set the affiliation of the profile to the returned token; for CSES, set the name of the account to it; for LeetCode, put it in the summary of the profile. Then call POST /me/accounts/{judge}/verify before the challenge expires.
+// @Tags Accounts
+// @Accept json
+// @Produce json
+// @Security Auth
+// @Param judge path string true "Judge" Enums(atcoder, cses, leetcode)
+// @Param request body accountChallengeRequest false "Challenge method; empty for the judge's default"
+// @Success 200 {object} models.HandleChallenge
+// @Failure 400 {object} string "No handle set or already verified"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Unknown judge"
+// @Router /me/accounts/{judge}/challenge [post]
+func (ctrl *AccountController) IssueChallenge(c *gin.Context) {
+	challenger, ok := ctrl.challenger(c)
+	if !ok {
+		return
+	}
+	var req accountChallengeRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	user, err := ctrl.Userrepo.GetByID(context.Background(), c.MustGet("userID").(primitive.ObjectID).Hex())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	judge := c.Param("judge")
+	handle, verified, _ := user.Account(judge)
+	if handle == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No handle set for " + judge})
+		return
+	}
+	if verified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Handle already verified"})
+		return
+	}
+
+	challenge, err := challenger.NewChallenge(handle, req.Method)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ctrl.Userrepo.SetAccountChallenge(context.Background(), user.ID, judge, challenge); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge"})
+		return
+	}
+	c.JSON(http.StatusOK, challenge)
+}
+
+// VerifyChallenge handles POST /me/accounts/:judge/verify
+// @Summary Complete judge account verification
+// @Description Check the outstanding challenge against the judge and mark the handle as verified
+// @Tags Accounts
+// @Produce json
+// @Security Auth
+// @Param judge path string true "Judge" Enums(atcoder, cses, leetcode)
+// @Success 200 {object} string "Handle verified"
+// @Failure 400 {object} string "Challenge not completed"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 404 {object} string "Unknown judge"
+// @Failure 409 {object} string "Handle verified by another user"
+// @Failure 502 {object} string "Judge unavailable"
+// @Router /me/accounts/{judge}/verify [post]
+func (ctrl *AccountController) VerifyChallenge(c *gin.Context) {
+	challenger, ok := ctrl.challenger(c)
+	if !ok {
+		return
+	}
+	user, err := ctrl.Userrepo.GetByID(context.Background(), c.MustGet("userID").(primitive.ObjectID).Hex())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	judge := c.Param("judge")
+	_, _, challenge := user.Account(judge)
+	if challenge == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No challenge issued"})
+		return
+	}
+
+	handle, err := challenger.CheckChallenge(c.Request.Context(), *challenge)
+	switch {
+	case errors.Is(err, verifier.ErrChallengeFailed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	owner, err := ctrl.Userrepo.GetByVerifiedAccount(context.Background(), judge, handle)
+	if err == nil && owner.ID != user.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Handle already verified by another user"})
+		return
+	}
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = ctrl.Userrepo.MarkAccountVerified(context.Background(), user.ID, judge, handle)
+	if errors.Is(err, repository.ErrHandleTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Handle already verified by another user"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Handle verified", "handle": handle})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/AbenezerWork/AASTU-CPC/auth"
 	"github.com/AbenezerWork/AASTU-CPC/models"
 	"github.com/AbenezerWork/AASTU-CPC/repository"
 
@@ -21,7 +22,7 @@ func NewArticleController(repo *repository.ArticleRepository) *ArticleController
 }
 
 // @Summary Create a new article
-// @Description Create a new article with the provided JSON body
+// @Description Create a new article with the provided JSON body, owned by the caller. It is a draft unless the caller may publish articles.
 // @Tags articles
 // @Accept json
 // @Produce json
@@ -36,6 +37,9 @@ func (ctrl *ArticleController) CreateArticle(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	session := c.MustGet("session").(models.Session)
+	article.OwnerID = session.UserID
+	needsReview(&article, session.Role)
 	createdArticle, err := ctrl.Repo.Create(context.Background(), &article)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -44,8 +48,16 @@ func (ctrl *ArticleController) CreateArticle(c *gin.Context) {
 	c.JSON(http.StatusOK, createdArticle)
 }
 
+// needsReview makes article a draft unless role may publish articles, so
+// that neither new articles nor edits to published ones go live unreviewed.
+func needsReview(article *models.Article, role string) {
+	if !auth.Can(role, auth.PermArticlePublish) {
+		article.Draft = true
+	}
+}
+
 // @Summary Get an article by ID
-// @Description Retrieve a single article by its ID
+// @Description Retrieve a single published article by its ID
 // @Tags articles
 // @Produce json
 // @Param id path string true "Article ID"
@@ -62,11 +74,15 @@ func (ctrl *ArticleController) GetArticleByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if article.Draft {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
 	c.JSON(http.StatusOK, article)
 }
 
 // @Summary Update an article
-// @Description Update an existing article by its ID
+// @Description Update an existing article by its ID. Edits by callers who may not publish articles turn it back into a draft.
 // @Tags articles
 // @Accept json
 // @Produce json
@@ -75,6 +91,7 @@ func (ctrl *ArticleController) GetArticleByID(c *gin.Context) {
 // @Param article body models.Article true "Updated article data"
 // @Success 200 {object} models.Article
 // @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
 // @Router /articlesedit/{id} [put]
 func (ctrl *ArticleController) UpdateArticle(c *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
@@ -82,12 +99,18 @@ func (ctrl *ArticleController) UpdateArticle(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
+	existing, ok := ctrl.authorize(c, id)
+	if !ok {
+		return
+	}
 	var article models.Article
 	if err := c.ShouldBindJSON(&article); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	article.ID = id
+	article.OwnerID = existing.OwnerID
+	needsReview(&article, c.MustGet("session").(models.Session).Role)
 	if err := ctrl.Repo.Update(context.Background(), &article); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -103,6 +126,7 @@ func (ctrl *ArticleController) UpdateArticle(c *gin.Context) {
 // @Param id path string true "Article ID"
 // @Success 200 {object} string "Article deleted successfully"
 // @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
 // @Router /articlesedit/{id} [delete]
 func (ctrl *ArticleController) DeleteArticle(c *gin.Context) {
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
@@ -110,6 +134,9 @@ func (ctrl *ArticleController) DeleteArticle(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
+	if _, ok := ctrl.authorize(c, id); !ok {
+		return
+	}
 	if err := ctrl.Repo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -117,8 +144,52 @@ func (ctrl *ArticleController) DeleteArticle(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Article deleted"})
 }
 
+// @Summary Publish an article
+// @Description Make a draft article public
+// @Tags articles
+// @Produce json
+// @Security Auth
+// @Param id path string true "Article ID"
+// @Success 200 {object} models.Article
+// @Failure 403 {object} string "Forbidden"
+// @Router /articlesedit/{id}/publish [put]
+func (ctrl *ArticleController) PublishArticle(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	article, err := ctrl.Repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+	article.Draft = false
+	if err := ctrl.Repo.Update(context.Background(), article); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, article)
+}
+
+// authorize returns the article with id if the caller may change it,
+// writing the error response itself otherwise. Setters may only change
+// articles they wrote.
+func (ctrl *ArticleController) authorize(c *gin.Context, id primitive.ObjectID) (*models.Article, bool) {
+	article, err := ctrl.Repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return nil, false
+	}
+	if !canModify(c, article.OwnerID, auth.PermArticleCreate, auth.PermArticleManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return nil, false
+	}
+	return article, true
+}
+
 // @Summary Get all articles
-// @Description Retrieve all articles with pagination filters search and sort
+// @Description Retrieve all published articles with pagination filters search and sort
 // @Tags articles
 // @Produce json
 // @Param page query int false "Page number"
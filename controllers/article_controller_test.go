@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/AbenezerWork/AASTU-CPC/auth"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+func TestEditsNeedReview(t *testing.T) {
+	tests := []struct {
+		role  string
+		draft bool
+	}{
+		{auth.RoleSetter, true},
+		{auth.RoleMentor, false},
+	}
+	for _, tt := range tests {
+		// a published article sent back unchanged apart from its text
+		article := models.Article{Draft: false}
+		needsReview(&article, tt.role)
+		if article.Draft != tt.draft {
+			t.Errorf("%s: draft = %v, want %v", tt.role, article.Draft, tt.draft)
+		}
+	}
+}
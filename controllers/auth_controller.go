@@ -2,33 +2,49 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"time"
 
+	"github.com/AbenezerWork/AASTU-CPC/auth"
 	"github.com/AbenezerWork/AASTU-CPC/models"
 	"github.com/AbenezerWork/AASTU-CPC/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"github.com/gorilla/sessions"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var store = sessions.NewCookieStore([]byte("secret-key"))
 var validate = validator.New()
 
 type AuthController struct {
 	UserRepo    *repository.UserRepository
 	SessionRepo *repository.SessionRepository
+	Sessions    *auth.Manager
 }
 
-func NewAuthController(ur *repository.UserRepository, sr *repository.SessionRepository) *AuthController {
+func NewAuthController(ur *repository.UserRepository, sr *repository.SessionRepository, sessions *auth.Manager) *AuthController {
 	return &AuthController{
 		UserRepo:    ur,
 		SessionRepo: sr,
+		Sessions:    sessions,
 	}
 }
 
+// unverify clears the verification state of a user sent by a client:
+// handles are only trusted after POST /me/codeforces/verify and
+// /me/accounts/{judge}/verify.
+func unverify(user *models.User) {
+	user.CodeforcesVerified = false
+	user.CodeforcesChallenge = nil
+	user.AtCoderVerified = false
+	user.AtCoderChallenge = nil
+	user.CSESVerified = false
+	user.CSESChallenge = nil
+	user.LeetCodeVerified = false
+	user.LeetCodeChallenge = nil
+}
+
 // @Summary Signup a new user
 // @Description Create a new user account
 // @Tags auth
@@ -55,6 +71,12 @@ func (ctrl *AuthController) Signup(c *gin.Context) {
 	}
 	user.PasswordHash = string(hashedPassword)
 	user.ID = primitive.NewObjectID()
+	// roles and divisions are given by admins through /users, and points
+	// only for solves
+	user.Role = auth.RoleMember
+	user.Division = ""
+	user.Score = 0
+	unverify(&user)
 
 	if err := ctrl.UserRepo.Create(context.Background(), &user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
@@ -65,7 +87,7 @@ func (ctrl *AuthController) Signup(c *gin.Context) {
 }
 
 // @Summary Login a user
-// @Description Authenticate a user and create a session
+// @Description Authenticate a user and create a session, set as the aastu_session cookie
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -78,71 +100,209 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.UserRepo.GetByUsername(context.Background(), credentials.Username)
+	user, err := ctrl.checkCredentials(credentials)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(credentials.Password))
+	token, session, err := ctrl.Sessions.Issue(context.Background(), *user, client(c))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
 		return
 	}
+	ctrl.Sessions.SetCookie(c.Writer, token, session.ExpiresAt)
 
-	//check to see if user is already logged in
-	if sess, err := ctrl.SessionRepo.GetByUserID(context.Background(), user.ID.Hex()); err == nil || time.Since(sess.Issued) < time.Hour*24*7 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User already signed in"})
+	c.JSON(http.StatusOK, gin.H{"message": "Logged in"})
+}
+
+// checkCredentials returns the user with the given username and password.
+func (ctrl *AuthController) checkCredentials(credentials models.Credentials) (*models.User, error) {
+	user, err := ctrl.UserRepo.GetByUsername(context.Background(), credentials.Username)
+	if err != nil {
+		return nil, err
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(credentials.Password))
+	if err != nil {
+		return nil, err
 	}
+	return user, nil
+}
 
-	session, _ := store.Get(c.Request, "session-name")
-	session.Values["authenticated"] = true
-	session.Values["userID"] = user.ID
-	session.ID = primitive.NewObjectID().Hex()
-	session.Save(c.Request, c.Writer)
+func client(c *gin.Context) auth.Client {
+	return auth.Client{UserAgent: c.Request.UserAgent(), IP: c.ClientIP()}
+}
 
-	sessionModel := &models.Session{
-		SessionID: session.ID,
-		UserID:    user.ID,
-		IsAdmin:   user.Role == "admin" || user.Role == "root",
-		Issued:    time.Now(),
-	}
-	if err := ctrl.SessionRepo.Create(context.Background(), sessionModel); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+// @Summary Get API tokens
+// @Description Bearer-token login for API clients such as the Telegram bot. With grant_type "password" it checks username and password like /login; with grant_type "refresh_token" it exchanges a refresh token for a new pair. Each refresh token works once; reusing one ends its session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.TokenRequest true "Token request"
+// @Success 200 {object} auth.TokenPair
+// @Failure 400 {object} string "Unsupported grant type"
+// @Failure 401 {object} string "Invalid credentials"
+// @Router /token [post]
+func (ctrl *AuthController) Token(c *gin.Context) {
+	var request models.TokenRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:     "Set-Cookie",
-		Value:    session.ID,
-		Path:     "/",
-		HttpOnly: true,
-		Expires:  time.Now().Add(time.Hour),
-	})
+	var tokens *auth.TokenPair
+	var err error
+	switch request.GrantType {
+	case models.GrantPassword:
+		user, err := ctrl.checkCredentials(models.Credentials{Username: request.Username, Password: request.Password})
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			return
+		}
+		tokens, err = ctrl.Sessions.IssueTokens(context.Background(), *user, client(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+			return
+		}
+	case models.GrantRefreshToken:
+		tokens, err = ctrl.Sessions.Refresh(context.Background(), request.RefreshToken)
+		switch {
+		case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, auth.ErrTokenExpired),
+			errors.Is(err, auth.ErrSessionExpired), errors.Is(err, auth.ErrSessionRevoked),
+			errors.Is(err, auth.ErrTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh session"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported grant type"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Logged in"})
+	c.JSON(http.StatusOK, tokens)
 }
 
 // @Summary Logout a user
-// @Description End the user's session
+// @Description End the user's session, given by its cookie or bearer access token
 // @Tags auth
 // @Produce json
 // @Router /logout [post]
 func (ctrl *AuthController) Logout(c *gin.Context) {
-	session, _ := store.Get(c.Request, "session-name")
-	session.Values["authenticated"] = false
-	session.Save(c.Request, c.Writer)
+	if token := auth.BearerToken(c.Request); token != "" {
+		session, err := ctrl.Sessions.AuthenticateBearer(context.Background(), token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		err = ctrl.SessionRepo.RevokeByID(context.Background(), session.UserID, session.ID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+		return
+	}
+
+	if token := ctrl.Sessions.Token(c.Request); token != "" {
+		err := ctrl.Sessions.Revoke(context.Background(), token)
+		if err != nil && !errors.Is(err, auth.ErrInvalidToken) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
+			return
+		}
+	}
+	ctrl.Sessions.ClearCookie(c.Writer)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// sessionView is a session as listed to its owner.
+type sessionView struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+// @Summary List my sessions
+// @Description List the caller's live sessions on all devices, most recently used first
+// @Tags auth
+// @Produce json
+// @Security Auth
+// @Success 200 {array} sessionView
+// @Failure 401 {object} string "Unauthorized"
+// @Router /me/sessions [get]
+func (ctrl *AuthController) GetSessions(c *gin.Context) {
+	current := c.MustGet("session").(models.Session)
+
+	sessions, err := ctrl.SessionRepo.ListActive(context.Background(), current.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list sessions"})
+		return
+	}
+	views := make([]sessionView, len(sessions))
+	for i, s := range sessions {
+		views[i] = sessionView{Session: s, Current: s.ID == current.ID}
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// @Summary Log out a session
+// @Description End one of the caller's sessions, e.g. on a lost device
+// @Tags auth
+// @Produce json
+// @Security Auth
+// @Param id path string true "Session ID"
+// @Success 200 {object} string "Session ended"
+// @Failure 404 {object} string "Session not found"
+// @Router /me/sessions/{id} [delete]
+func (ctrl *AuthController) DeleteSession(c *gin.Context) {
+	current := c.MustGet("session").(models.Session)
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
 
-	if err := ctrl.SessionRepo.Delete(context.Background(), session.ID); err != nil {
+	err = ctrl.SessionRepo.RevokeByID(context.Background(), current.UserID, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete session"})
 		return
 	}
+	if id == current.ID {
+		ctrl.Sessions.ClearCookie(c.Writer)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+	c.JSON(http.StatusOK, gin.H{"message": "Session ended"})
+}
+
+// @Summary Log out everywhere
+// @Description End all of the caller's sessions, including this one
+// @Tags auth
+// @Produce json
+// @Security Auth
+// @Success 200 {object} string "Logged out everywhere"
+// @Failure 401 {object} string "Unauthorized"
+// @Router /me/sessions [delete]
+func (ctrl *AuthController) DeleteSessions(c *gin.Context) {
+	current := c.MustGet("session").(models.Session)
+
+	ended, err := ctrl.SessionRepo.RevokeAll(context.Background(), current.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sessions"})
+		return
+	}
+	ctrl.Sessions.ClearCookie(c.Writer)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out everywhere", "sessions": ended})
 }
 
 // @Summary Create a new user
-// @Description Create a new user with the provided JSON body
+// @Description Create a new user with the provided JSON body. Role is one of member (the default), setter, mentor, admin and root; only root may create admins and roots.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -162,6 +322,12 @@ func (ctrl *AuthController) CreateUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if user.Role == "" {
+		user.Role = auth.RoleMember
+	}
+	if !checkRole(c, user.Role) {
+		return
+	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
 	if err != nil {
@@ -170,6 +336,7 @@ func (ctrl *AuthController) CreateUser(c *gin.Context) {
 	}
 	user.PasswordHash = string(hashedPassword)
 	user.ID = primitive.NewObjectID()
+	unverify(&user)
 
 	if err := ctrl.UserRepo.Create(context.Background(), &user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
@@ -201,7 +368,7 @@ func (ctrl *AuthController) GetUserByID(c *gin.Context) {
 }
 
 // @Summary Update a user
-// @Description Update an existing user's details
+// @Description Update an existing user's details. An empty password keeps the current one; a changed handle has to be verified again. Only root may grant the admin and root roles or change admins and roots.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -218,17 +385,41 @@ func (ctrl *AuthController) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	existing, ok := ctrl.loadManaged(c, id)
+	if !ok {
+		return
+	}
+
 	var user models.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if user.Role == "" {
+		user.Role = auth.RoleMember
+	}
+	if !checkRole(c, user.Role) {
+		return
+	}
 
-	user.ID = id
-	if err := ctrl.UserRepo.Update(context.Background(), &user); err != nil {
+	if user.PasswordHash != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.PasswordHash), bcrypt.DefaultCost)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+		user.PasswordHash = string(hashedPassword)
+	}
+	if err := ctrl.UserRepo.UpdateProfile(context.Background(), existing, &user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
+	if user.Role != existing.Role {
+		if err := ctrl.SessionRepo.SetRole(context.Background(), id, user.Role); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sessions"})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully"})
 }
@@ -249,10 +440,53 @@ func (ctrl *AuthController) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	if _, ok := ctrl.loadManaged(c, id); !ok {
+		return
+	}
+
 	if err := ctrl.UserRepo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
+	if _, err := ctrl.SessionRepo.RevokeAll(context.Background(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sessions"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted successfully"})
 }
+
+// privileged roles can only be granted, and their holders managed, with
+// auth.PermRoleManage.
+func privileged(role string) bool {
+	return role == auth.RoleAdmin || role == auth.RoleRoot
+}
+
+// checkRole reports whether the caller may give a user role, writing the
+// error response itself if not.
+func checkRole(c *gin.Context, role string) bool {
+	if !auth.ValidRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role"})
+		return false
+	}
+	if privileged(role) && !auth.Can(c.MustGet("session").(models.Session).Role, auth.PermRoleManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return false
+	}
+	return true
+}
+
+// loadManaged returns the user with id if the caller may manage them,
+// writing the error response itself otherwise.
+func (ctrl *AuthController) loadManaged(c *gin.Context, id primitive.ObjectID) (*models.User, bool) {
+	user, err := ctrl.UserRepo.GetByID(context.Background(), id.Hex())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return nil, false
+	}
+	if privileged(user.Role) && !auth.Can(c.MustGet("session").(models.Session).Role, auth.PermRoleManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return nil, false
+	}
+	return user, true
+}
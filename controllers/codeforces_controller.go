@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/AbenezerWork/AASTU-CPC/codeforces"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/verifier"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CodeforcesController handles Codeforces account linking and exposes the
+// state of the shared Codeforces client.
+type CodeforcesController struct {
+	Client   *codeforces.Client
+	Verifier *verifier.Codeforces
+	Userrepo *repository.UserRepository
+}
+
+// NewCodeforcesController initializes a new CodeforcesController.
+func NewCodeforcesController(client *codeforces.Client, v *verifier.Codeforces, ur *repository.UserRepository) *CodeforcesController {
+	return &CodeforcesController{
+		Client:   client,
+		Verifier: v,
+		Userrepo: ur,
+	}
+}
+
+type challengeRequest struct {
+	Method string `json:"method" binding:"required,oneof=compile-error first-name"`
+}
+
+// IssueChallenge handles POST /me/codeforces/challenge
+// @Summary Start Codeforces handle verification
+// @Description Issue a challenge proving the logged in user owns their Codeforces handle. With method "compile-error" submit code that does not compile to the returned problem; with "first-name" set the first name of the Codeforces profile to the returned token. Then call POST /me/codeforces/verify before the challenge expires.
+// @Tags Codeforces
+// @Accept json
+// @Produce json
+// @Security Auth
+// @Param request body challengeRequest true "Challenge method"
+// @Success 200 {object} models.HandleChallenge
+// @Failure 401 {object} string "Unauthorized"
+// @Router /me/codeforces/challenge [post]
+func (ctrl *CodeforcesController) IssueChallenge(c *gin.Context) {
+	var req challengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := ctrl.Userrepo.GetByID(context.Background(), c.MustGet("userID").(primitive.ObjectID).Hex())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.CodeforcesUsername == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No Codeforces handle set"})
+		return
+	}
+	if user.CodeforcesVerified {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Codeforces handle already verified"})
+		return
+	}
+
+	challenge, err := ctrl.Verifier.NewChallenge(user.CodeforcesUsername, req.Method)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := ctrl.Userrepo.SetCodeforcesChallenge(context.Background(), user.ID, challenge); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge"})
+		return
+	}
+	c.JSON(http.StatusOK, challenge)
+}
+
+// VerifyChallenge handles POST /me/codeforces/verify
+// @Summary Complete Codeforces handle verification
+// @Description Check the outstanding challenge against Codeforces and mark the handle as verified
+// @Tags Codeforces
+// @Produce json
+// @Security Auth
+// @Success 200 {object} string "Codeforces handle verified"
+// @Failure 400 {object} string "Challenge not completed"
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 409 {object} string "Handle verified by another user"
+// @Failure 502 {object} string "Codeforces unavailable"
+// @Router /me/codeforces/verify [post]
+func (ctrl *CodeforcesController) VerifyChallenge(c *gin.Context) {
+	user, err := ctrl.Userrepo.GetByID(context.Background(), c.MustGet("userID").(primitive.ObjectID).Hex())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.CodeforcesChallenge == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No challenge issued"})
+		return
+	}
+
+	handle, err := ctrl.Verifier.CheckChallenge(c.Request.Context(), *user.CodeforcesChallenge)
+	switch {
+	case errors.Is(err, verifier.ErrChallengeFailed):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	owner, err := ctrl.Userrepo.GetByVerifiedCodeforcesHandle(context.Background(), handle)
+	if err == nil && owner.ID != user.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": "Codeforces handle already verified by another user"})
+		return
+	}
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = ctrl.Userrepo.MarkCodeforcesVerified(context.Background(), user.ID, handle)
+	if errors.Is(err, repository.ErrHandleTaken) {
+		// verified by someone else since the check above
+		c.JSON(http.StatusConflict, gin.H{"error": "Codeforces handle already verified by another user"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Codeforces handle verified", "handle": handle})
+}
+
+// Stats handles GET /codeforces/stats
+// @Summary Codeforces client statistics
+// @Description Request, cache and rate limiter counters of the outbound Codeforces client
+// @Tags Codeforces
+// @Produce json
+// @Security AdminAuth
+// @Success 200 {object} codeforces.Stats
+// @Failure 401 {object} string "Unauthorized"
+// @Router /codeforces/stats [get]
+func (ctrl *CodeforcesController) Stats(c *gin.Context) {
+	c.JSON(http.StatusOK, ctrl.Client.Stats())
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/AbenezerWork/AASTU-CPC/importer"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/gin-gonic/gin"
+)
+
+// ImportController handles importing problems from external judges.
+type ImportController struct {
+	Codeforces *importer.Codeforces
+	CSES       *importer.CSES
+}
+
+// NewImportController initializes a new ImportController.
+func NewImportController(cf *importer.Codeforces, cses *importer.CSES) *ImportController {
+	return &ImportController{Codeforces: cf, CSES: cses}
+}
+
+// ImportCodeforcesProblemset handles POST /import/codeforces/problemset
+// @Summary Import the Codeforces problemset
+// @Description Upsert Codeforces problemset problems, optionally filtered by tags (all must match) and rating range. Re-importing refreshes title, rating and tags.
+// @Tags Import
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param query body importer.ProblemsetQuery false "Filters"
+// @Success 200 {object} importer.Result
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 502 {object} string "Codeforces unavailable"
+// @Router /import/codeforces/problemset [post]
+func (ctrl *ImportController) ImportCodeforcesProblemset(c *gin.Context) {
+	var query importer.ProblemsetQuery
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&query); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	result, err := ctrl.Codeforces.ImportProblemset(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+type importContestResponse struct {
+	ProblemSet *models.ProblemSet `json:"problem_set"`
+	Result     *importer.Result   `json:"result"`
+}
+
+// ImportCodeforcesContest handles POST /import/codeforces/contest
+// @Summary Import a Codeforces contest or gym
+// @Description Upsert every problem of a contest or gym and a problem set listing them in index order. Re-importing refreshes the set.
+// @Tags Import
+// @Accept json
+// @Produce json
+// @Security AdminAuth
+// @Param query body importer.ContestQuery true "Contest to import"
+// @Success 200 {object} importContestResponse
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 502 {object} string "Codeforces unavailable"
+// @Router /import/codeforces/contest [post]
+func (ctrl *ImportController) ImportCodeforcesContest(c *gin.Context) {
+	var query importer.ContestQuery
+	if err := c.ShouldBindJSON(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.ContestID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contest_id"})
+		return
+	}
+
+	set, result, err := ctrl.Codeforces.ImportContest(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, importContestResponse{ProblemSet: set, Result: result})
+}
+
+// ImportCSES handles POST /import/cses
+// @Summary Import the CSES problem set
+// @Description Upsert every CSES task with its category as a tag
+// @Tags Import
+// @Produce json
+// @Security AdminAuth
+// @Success 200 {object} importer.Result
+// @Failure 401 {object} string "Unauthorized"
+// @Failure 502 {object} string "CSES unavailable"
+// @Router /import/cses [post]
+func (ctrl *ImportController) ImportCSES(c *gin.Context) {
+	result, err := ctrl.CSES.Import(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
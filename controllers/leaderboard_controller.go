@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// LeaderboardController handles HTTP requests related to rankings.
+type LeaderboardController struct {
+	Repo *repository.LeaderboardRepository
+}
+
+// NewLeaderboardController initializes a new LeaderboardController.
+func NewLeaderboardController(repo *repository.LeaderboardRepository) *LeaderboardController {
+	return &LeaderboardController{Repo: repo}
+}
+
+// semesterStart returns the start of the current semester. SEMESTER_START
+// (YYYY-MM-DD) overrides the default of September 1st and February 1st.
+func semesterStart(now time.Time) time.Time {
+	if start, err := time.Parse("2006-01-02", os.Getenv("SEMESTER_START")); err == nil {
+		return start
+	}
+	year := now.Year()
+	switch {
+	case now.Month() >= time.September:
+		return time.Date(year, time.September, 1, 0, 0, 0, 0, time.UTC)
+	case now.Month() >= time.February:
+		return time.Date(year, time.February, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(year-1, time.September, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// GetLeaderboard handles GET /leaderboard
+// @Summary Get the leaderboard
+// @Description Rank users by points from accepted submissions in a time window. Ties are broken by the earliest last accepted solve.
+// @Tags Leaderboard
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of entries per page"
+// @Param window query string false "all (default), semester, 30d or custom"
+// @Param from query string false "Start of a custom window (YYYY-MM-DD)"
+// @Param to query string false "End of a custom window, exclusive (YYYY-MM-DD)"
+// @Param division query string false "Only users of this division"
+// @Param tag query string false "Only solves of problems with this tag"
+// @Success 200 {object} models.Leaderboard
+// @Router /leaderboard [get]
+func (ctrl *LeaderboardController) GetLeaderboard(c *gin.Context) {
+	filter := models.LeaderboardFilter{
+		Division: c.Query("division"),
+		Tag:      c.Query("tag"),
+		Page:     1,
+		Limit:    20,
+	}
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			filter.Page = p
+		}
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100 {
+			filter.Limit = l
+		}
+	}
+
+	now := time.Now()
+	switch c.DefaultQuery("window", "all") {
+	case "all":
+	case "semester":
+		filter.From = semesterStart(now)
+	case "30d":
+		filter.From = now.AddDate(0, 0, -30)
+	case "custom":
+		var err error
+		if filter.From, err = time.Parse("2006-01-02", c.Query("from")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from"})
+			return
+		}
+		if toStr := c.Query("to"); toStr != "" {
+			if filter.To, err = time.Parse("2006-01-02", toStr); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to"})
+				return
+			}
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window"})
+		return
+	}
+
+	leaderboard, err := ctrl.Repo.Get(context.Background(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, leaderboard)
+}
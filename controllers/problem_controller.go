@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/AbenezerWork/AASTU-CPC/auth"
 	"github.com/AbenezerWork/AASTU-CPC/models"
 	"github.com/AbenezerWork/AASTU-CPC/repository"
 	"github.com/gin-gonic/gin"
@@ -13,17 +14,18 @@ import (
 
 // ProblemController handles HTTP requests related to problems.
 type ProblemController struct {
-	Repo *repository.ProblemRepository
+	Repo     *repository.ProblemRepository
+	Testrepo *repository.TestRepository
 }
 
 // NewProblemController initializes a new ProblemController.
-func NewProblemController(repo *repository.ProblemRepository) *ProblemController {
-	return &ProblemController{Repo: repo}
+func NewProblemController(repo *repository.ProblemRepository, tr *repository.TestRepository) *ProblemController {
+	return &ProblemController{Repo: repo, Testrepo: tr}
 }
 
 // CreateProblem handles POST /problemsedit
 // @Summary Create a new problem
-// @Description Create a new problem in the database NOTE: Don't enter the id
+// @Description Create a new problem in the database, owned by the caller NOTE: Don't enter the id. Without problem:manage only the author, title, statement, tags and limits are taken; the problem is native and its difficulty, contest, checker and interactor are left for a manager to set.
 // @Tags Problems
 // @Accept json
 // @Produce json
@@ -39,6 +41,10 @@ func (ctrl *ProblemController) CreateProblem(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error1": err.Error()})
 		return
 	}
+	if !auth.Can(c.MustGet("session").(models.Session).Role, auth.PermProblemManage) {
+		problem = setterFields(models.Problem{Source: models.JudgeNative}, problem)
+	}
+	problem.OwnerID = c.MustGet("userID").(primitive.ObjectID)
 	createdProblem, err := ctrl.Repo.Create(context.Background(), &problem)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error2": err.Error()})
@@ -68,12 +74,51 @@ func (ctrl *ProblemController) GetProblemByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	hideGrading(problem)
 	c.JSON(http.StatusOK, problem)
 }
 
+// authorize returns the problem with id if the caller may change it,
+// writing the error response itself otherwise. Setters may only change
+// problems they created.
+func (ctrl *ProblemController) authorize(c *gin.Context, id primitive.ObjectID) (*models.Problem, bool) {
+	problem, err := ctrl.Repo.GetByID(context.Background(), id.Hex())
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return nil, false
+	}
+	if !canModify(c, problem.OwnerID, auth.PermProblemCreate, auth.PermProblemManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return nil, false
+	}
+	return problem, true
+}
+
+// setterFields returns base with the fields a setter may write taken from
+// req. The judge, the difficulty points are awarded by, the contest and
+// the grading programs need PermProblemManage. The limits are capped by the
+// judge, whoever sets them.
+func setterFields(base, req models.Problem) models.Problem {
+	base.Author = req.Author
+	base.Title = req.Title
+	base.ProblemStatement = req.ProblemStatement
+	base.Tags = req.Tags
+	base.TimeLimit = req.TimeLimit
+	base.MemoryLimit = req.MemoryLimit
+	base.OutputLimit = req.OutputLimit
+	return base
+}
+
+// hideGrading drops the checker and interactor sources, which are for the
+// judge only.
+func hideGrading(problem *models.Problem) {
+	problem.Checker = nil
+	problem.Interactor = nil
+}
+
 // UpdateProblem handles PUT /problemsedit/:id
 // @Summary Update a problem
-// @Description Update an existing problem by its ID NOTE: Don't update the id
+// @Description Update an existing problem by its ID NOTE: Don't update the id. Without problem:manage only the author, title, statement, tags and limits are changed.
 // @Tags Problems
 // @Accept json
 // @Produce json
@@ -82,25 +127,28 @@ func (ctrl *ProblemController) GetProblemByID(c *gin.Context) {
 // @Param problem body models.Problem true "Updated problem data"
 // @Success 200 {object} models.Problem
 // @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
 // @Router /problemsedit/{id} [put]
 func (ctrl *ProblemController) UpdateProblem(c *gin.Context) {
-	session := c.MustGet("session").(models.Session)
-	if session.UserID == primitive.NilObjectID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
+	existing, ok := ctrl.authorize(c, id)
+	if !ok {
+		return
+	}
 	var problem models.Problem
 	if err := c.ShouldBindJSON(&problem); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !auth.Can(c.MustGet("session").(models.Session).Role, auth.PermProblemManage) {
+		problem = setterFields(*existing, problem)
+	}
 	problem.ID = id
+	problem.OwnerID = existing.OwnerID
 	if err := ctrl.Repo.Update(context.Background(), &problem); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -110,26 +158,30 @@ func (ctrl *ProblemController) UpdateProblem(c *gin.Context) {
 
 // DeleteProblem handles DELETE /problemsedit/:id
 // @Summary Delete a problem
-// @Description Delete a problem by its ID
+// @Description Delete a problem by its ID together with its tests
 // @Tags Problems
 // @Produce json
 // @Security Auth
 // @Param id path string true "Problem ID"
 // @Success 200 {object} string "Problem deleted successfully"
 // @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Forbidden"
 // @Router /problemsedit/{id} [delete]
 func (ctrl *ProblemController) DeleteProblem(c *gin.Context) {
-	session := c.MustGet("session").(models.Session)
-	if session.UserID == primitive.NilObjectID {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
 	id, err := primitive.ObjectIDFromHex(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
+	if _, ok := ctrl.authorize(c, id); !ok {
+		return
+	}
+	// the tests go first, so a failure leaves a problem to delete again
+	// rather than files nothing refers to
+	if err := ctrl.Testrepo.Delete(context.Background(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	if err := ctrl.Repo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -191,6 +243,9 @@ func (ctrl *ProblemController) GetProblems(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	for i := range problems {
+		hideGrading(&problems[i])
+	}
 
 	c.JSON(http.StatusOK, problems)
 }
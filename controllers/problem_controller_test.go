@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+func TestSetterFieldsKeepsManagedFields(t *testing.T) {
+	existing := models.Problem{
+		Source:     models.JudgeNative,
+		Difficulty: 1200,
+		Title:      "Old",
+		Checker:    &models.Program{Source: "checker"},
+	}
+	req := models.Problem{
+		Source:     "codeforces",
+		Difficulty: 3500,
+		ContestID:  "1",
+		Index:      "A",
+		Title:      "New",
+		TimeLimit:  2000,
+		Checker:    &models.Program{Source: "mine"},
+		Interactor: &models.Program{Source: "mine"},
+	}
+	got := setterFields(existing, req)
+	if got.Title != "New" || got.TimeLimit != 2000 {
+		t.Errorf("editable fields not taken: %+v", got)
+	}
+	if got.Source != models.JudgeNative || got.Difficulty != 1200 || got.ContestID != "" || got.Index != "" {
+		t.Errorf("managed fields changed: %+v", got)
+	}
+	if got.Checker.Source != "checker" || got.Interactor != nil {
+		t.Errorf("grading programs changed: %+v, %+v", got.Checker, got.Interactor)
+	}
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProblemSetController handles HTTP requests related to problem sets.
+type ProblemSetController struct {
+	Repo     *repository.ProblemSetRepository
+	Probrepo *repository.ProblemRepository
+}
+
+// NewProblemSetController initializes a new ProblemSetController.
+func NewProblemSetController(repo *repository.ProblemSetRepository, pr *repository.ProblemRepository) *ProblemSetController {
+	return &ProblemSetController{Repo: repo, Probrepo: pr}
+}
+
+// @Summary Create a new problem set
+// @Description Create a new problem set from existing problem IDs, in order
+// @Tags problemsets
+// @Accept json
+// @Produce json
+// @Security Auth
+// @Param problemset body models.ProblemSet true "Problem set to create"
+// @Success 200 {object} models.ProblemSet
+// @Failure 401 {object} string "Unauthorized"
+// @Router /problemsetsedit [post]
+func (ctrl *ProblemSetController) CreateProblemSet(c *gin.Context) {
+	var set models.ProblemSet
+	if err := c.ShouldBindJSON(&set); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	set.ID = primitive.NilObjectID
+	set.Problems = nil
+	set.CreatedAt = time.Now()
+	createdSet, err := ctrl.Repo.Create(context.Background(), &set)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, createdSet)
+}
+
+// @Summary Get a problem set by ID
+// @Description Retrieve a problem set together with its problems in order
+// @Tags problemsets
+// @Produce json
+// @Param id path string true "Problem set ID"
+// @Success 200 {object} models.ProblemSet
+// @Router /problemsets/{id} [get]
+func (ctrl *ProblemSetController) GetProblemSetByID(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	set, err := ctrl.Repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem set not found"})
+		return
+	}
+	set.Problems, err = ctrl.Probrepo.GetByIDs(context.Background(), set.ProblemIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range set.Problems {
+		hideGrading(&set.Problems[i])
+	}
+	c.JSON(http.StatusOK, set)
+}
+
+// @Summary Update a problem set
+// @Description Update an existing problem set by its ID
+// @Tags problemsets
+// @Accept json
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem set ID"
+// @Param problemset body models.ProblemSet true "Updated problem set data"
+// @Success 200 {object} models.ProblemSet
+// @Failure 401 {object} string "Unauthorized"
+// @Router /problemsetsedit/{id} [put]
+func (ctrl *ProblemSetController) UpdateProblemSet(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var set models.ProblemSet
+	if err := c.ShouldBindJSON(&set); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	set.ID = id
+	set.Problems = nil
+	if err := ctrl.Repo.Update(context.Background(), &set); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, set)
+}
+
+// @Summary Delete a problem set
+// @Description Delete a problem set by its ID. Its problems are kept.
+// @Tags problemsets
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem set ID"
+// @Success 200 {object} string "Problem set deleted successfully"
+// @Failure 401 {object} string "Unauthorized"
+// @Router /problemsetsedit/{id} [delete]
+func (ctrl *ProblemSetController) DeleteProblemSet(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if err := ctrl.Repo.Delete(context.Background(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Problem set deleted"})
+}
+
+// @Summary Get all problem sets
+// @Description Retrieve problem sets, newest first
+// @Tags problemsets
+// @Produce json
+// @Param page query int false "Page number"
+// @Param limit query int false "Number of items per page"
+// @Param division query string false "Only sets of this division"
+// @Success 200 {array} models.ProblemSet
+// @Router /problemsets [get]
+func (ctrl *ProblemSetController) GetProblemSets(c *gin.Context) {
+	page := 1
+	if pageStr := c.Query("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			page = p
+		}
+	}
+
+	limit := 10
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	sets, err := ctrl.Repo.GetAll(context.Background(), page, limit, c.Query("division"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, sets)
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RatingController serves mirrored Codeforces rating history.
+type RatingController struct {
+	Repo *repository.RatingHistoryRepository
+}
+
+// NewRatingController initializes a new RatingController.
+func NewRatingController(repo *repository.RatingHistoryRepository) *RatingController {
+	return &RatingController{Repo: repo}
+}
+
+// GetRatingHistory handles GET /users/:id/rating-history
+// @Summary Get a user's Codeforces rating history
+// @Description Retrieve the rated contests of a user, oldest first, for drawing a rating graph
+// @Tags users
+// @Produce json
+// @Security Auth
+// @Param id path string true "User ID"
+// @Success 200 {array} models.RatingChange
+// @Failure 401 {object} string "Unauthorized"
+// @Router /users/{id}/rating-history [get]
+func (ctrl *RatingController) GetRatingHistory(c *gin.Context) {
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	history, err := ctrl.Repo.GetByUserID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
@@ -2,73 +2,188 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/AbenezerWork/AASTU-CPC/judge"
 	"github.com/AbenezerWork/AASTU-CPC/models"
 	"github.com/AbenezerWork/AASTU-CPC/repository"
-	"github.com/AbenezerWork/AASTU-CPC/utils"
+	"github.com/AbenezerWork/AASTU-CPC/verifier"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// maxCodeSize bounds the source code of a native submission.
+const maxCodeSize = 64 << 10
+
 // SubmissionController handles HTTP requests related to submissions.
 type SubmissionController struct {
-	Subrepo  *repository.SubmissionRepository
-	Probrepo *repository.ProblemRepository
-	Userrepo *repository.UserRepository
+	Subrepo   *repository.SubmissionRepository
+	Jobrepo   *repository.JudgeJobRepository
+	Probrepo  *repository.ProblemRepository
+	Userrepo  *repository.UserRepository
+	Verifiers *verifier.Registry
 }
 
 // NewSubmissionController initializes a new SubmissionController.
-func NewSubmissionController(sr *repository.SubmissionRepository, pr *repository.ProblemRepository, ur *repository.UserRepository) *SubmissionController {
+func NewSubmissionController(sr *repository.SubmissionRepository, jr *repository.JudgeJobRepository, pr *repository.ProblemRepository, ur *repository.UserRepository, vr *verifier.Registry) *SubmissionController {
 	return &SubmissionController{
-		Subrepo:  sr,
-		Probrepo: pr,
-		Userrepo: ur,
+		Subrepo:   sr,
+		Jobrepo:   jr,
+		Probrepo:  pr,
+		Userrepo:  ur,
+		Verifiers: vr,
 	}
 }
 
 // ValidateSubmission handles POST /validate-submission
 // @Summary Validate a submission
-// @Description Validate a user's submission for a problem
+// @Description Queue the logged in user's submission for verification against the judge the problem comes from. For native problems send "language" (see GET /languages) and "code" instead of a judge submission ID. Poll GET /submissions/{id} for the result.
 // @Tags Submissions
 // @Accept json
 // @Produce json
 // @Security Auth
 // @Param submission body models.Submission true "Submission data"
-// @Success 200 {object} models.Submission
+// @Success 202 {object} models.Submission
+// @Failure 400 {object} string "Unsupported judge or language, or invalid submission"
 // @Failure 401 {object} string "Unauthorized"
+// @Failure 403 {object} string "Judge handle not verified"
+// @Failure 409 {object} string "Submission already validated or problem already solved"
 // @Router /validate-submission [post]
 func (sc *SubmissionController) ValidateSubmission(c *gin.Context) {
 	var submission models.Submission
 
 	if err := c.ShouldBindJSON(&submission); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error1": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	problem, err := sc.Probrepo.GetByID(context.Background(), submission.ProblemID)
-
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error2": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	submission.UserID = c.MustGet("userID").(primitive.ObjectID).Hex()
 
-	if problem.Source == "codeforces" {
+	if problem.Source == models.JudgeNative {
+		// graded by the built-in judge from the submitted code
+		if _, ok := judge.Lookup(submission.Language); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported language"})
+			return
+		}
+		if submission.Code == "" || len(submission.Code) > maxCodeSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Code must be between 1 byte and 64 KiB"})
+			return
+		}
+		submission.Submission = ""
+	} else {
+		v, err := sc.Verifiers.Get(problem.Source)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		user, err := sc.Userrepo.GetByID(context.Background(), submission.UserID)
-
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error3": err.Error()})
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		handle, err := v.Handle(*user)
+		if err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		if ref, ok := v.(verifier.Referencer); ok {
+			submission.Submission = ref.Reference(*problem, handle)
+		} else if submission.Submission == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Submission id required"})
 			return
 		}
+		submission.Language = ""
+		submission.Code = ""
+	}
 
-		err, bl := utils.GetAndCheckAdmission(*problem, submission.Submission, user.CodeforcesUsername)
+	solved, err := sc.Subrepo.HasAccepted(context.Background(), submission.UserID, submission.ProblemID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if solved {
+		c.JSON(http.StatusConflict, gin.H{"error": "Problem already solved"})
+		return
+	}
 
-		if !bl {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		}
+	now := time.Now()
+	submission.ID = primitive.NilObjectID
+	submission.Judge = problem.Source
+	submission.Status = models.SubmissionPending
+	submission.Attempts = 0
+	submission.Error = ""
+	submission.Verdict = ""
+	submission.CompileOutput = ""
+	submission.Tests = nil
+	submission.NextAttemptAt = now
+	submission.CreatedAt = now
+	submission.UpdatedAt = now
 
-		sc.Subrepo.Create(context.Background(), &submission)
+	if err := sc.Subrepo.Create(context.Background(), &submission); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Submission already validated"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if submission.Judge == models.JudgeNative {
+		if err := sc.Jobrepo.Enqueue(context.Background(), submission.ID); err != nil {
+			// don't leave a submission behind that nothing will judge
+			sc.Subrepo.Delete(context.Background(), submission.ID.Hex())
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	c.JSON(http.StatusAccepted, submission)
+}
 
+// GetSubmission handles GET /submissions/:id
+// @Summary Get a submission
+// @Description Retrieve a submission and its verification status (pending, verifying, accepted, rejected or error). While a native submission is judged, "tests" grows towards "tests_total"; once done it carries the judge's verdict.
+// @Tags Submissions
+// @Produce json
+// @Security Auth
+// @Param id path string true "Submission ID"
+// @Success 200 {object} models.Submission
+// @Failure 404 {object} string "Submission not found"
+// @Router /submissions/{id} [get]
+func (sc *SubmissionController) GetSubmission(c *gin.Context) {
+	submission, err := sc.Subrepo.GetByID(context.Background(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Submission not found"})
+		return
+	}
+	if submission.UserID != c.MustGet("userID").(primitive.ObjectID).Hex() {
+		// only the author gets to read the code
+		submission.Code = ""
 	}
+	c.JSON(http.StatusOK, submission)
+}
 
-	//TODO: finish the submission checker for other platforms
+type languageResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// GetLanguages handles GET /languages
+// @Summary List judge languages
+// @Description List the languages native problems can be solved in, for the "language" field of a submission
+// @Tags Submissions
+// @Produce json
+// @Success 200 {array} languageResponse
+// @Router /languages [get]
+func (sc *SubmissionController) GetLanguages(c *gin.Context) {
+	languages := []languageResponse{}
+	for _, lang := range judge.Languages() {
+		languages = append(languages, languageResponse{ID: lang.ID, Name: lang.Name})
+	}
+	c.JSON(http.StatusOK, languages)
 }
@@ -0,0 +1,555 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/AbenezerWork/AASTU-CPC/auth"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/testcase"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxArchiveSize bounds an uploaded zip of tests.
+const maxArchiveSize = 256 << 20
+
+// TestController manages the judge test data of problems.
+type TestController struct {
+	Repo     *repository.TestRepository
+	Probrepo *repository.ProblemRepository
+}
+
+// NewTestController initializes a new TestController.
+func NewTestController(repo *repository.TestRepository, pr *repository.ProblemRepository) *TestController {
+	return &TestController{Repo: repo, Probrepo: pr}
+}
+
+type testGroupRequest struct {
+	Name   string `json:"name" binding:"required"`
+	Points int    `json:"points"`
+}
+
+type testOrderRequest struct {
+	GroupIDs []string `json:"group_ids" binding:"required"`
+}
+
+type testCaseRequest struct {
+	Name   *string `json:"name"`
+	Sample *bool   `json:"sample"`
+}
+
+// load returns the tests of the problem in the path, writing the error
+// response itself if there is none or the caller may not change the
+// problem.
+func (ctrl *TestController) load(c *gin.Context) (*models.ProblemTests, bool) {
+	problem, err := ctrl.Probrepo.GetByID(context.Background(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem not found"})
+		return nil, false
+	}
+	if !canModify(c, problem.OwnerID, auth.PermProblemCreate, auth.PermProblemManage) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+		return nil, false
+	}
+	tests, err := ctrl.Repo.Get(context.Background(), problem.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return tests, true
+}
+
+// group returns the group named by the :group path parameter.
+func group(c *gin.Context, tests *models.ProblemTests) (*models.TestGroup, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("group"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return nil, false
+	}
+	g := tests.Group(id)
+	if g == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Test group not found"})
+		return nil, false
+	}
+	return g, true
+}
+
+func (ctrl *TestController) save(c *gin.Context, tests *models.ProblemTests) {
+	if err := ctrl.Repo.Save(context.Background(), tests); err != nil {
+		saveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tests)
+}
+
+// saveError writes the response for a failed save of the tests.
+func saveError(c *gin.Context, err error) {
+	if errors.Is(err, repository.ErrTestsChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The tests were changed by someone else, reload and try again"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+// @Summary Get the tests of a problem
+// @Description Retrieve the ordered test groups of a problem with file checksums
+// @Tags tests
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Success 200 {object} models.ProblemTests
+// @Failure 404 {object} string "Problem not found"
+// @Router /problemsedit/{id}/tests [get]
+func (ctrl *TestController) GetTests(c *gin.Context) {
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, tests)
+}
+
+// @Summary Add a test group
+// @Description Append an empty test group worth the given points
+// @Tags tests
+// @Accept json
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Param group body testGroupRequest true "Group name and points"
+// @Success 200 {object} models.ProblemTests
+// @Router /problemsedit/{id}/tests/groups [post]
+func (ctrl *TestController) CreateGroup(c *gin.Context) {
+	var req testGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Points < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "points must not be negative"})
+		return
+	}
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+	tests.Groups = append(tests.Groups, models.TestGroup{
+		ID:     primitive.NewObjectID(),
+		Name:   req.Name,
+		Points: req.Points,
+		Tests:  []models.TestCase{},
+	})
+	ctrl.save(c, tests)
+}
+
+// @Summary Update a test group
+// @Description Rename a test group or change its points
+// @Tags tests
+// @Accept json
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Param group path string true "Group ID"
+// @Param body body testGroupRequest true "Group name and points"
+// @Success 200 {object} models.ProblemTests
+// @Router /problemsedit/{id}/tests/groups/{group} [put]
+func (ctrl *TestController) UpdateGroup(c *gin.Context) {
+	var req testGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Points < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "points must not be negative"})
+		return
+	}
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+	g, ok := group(c, tests)
+	if !ok {
+		return
+	}
+	g.Name = req.Name
+	g.Points = req.Points
+	ctrl.save(c, tests)
+}
+
+// @Summary Delete a test group
+// @Description Delete a test group together with its test files
+// @Tags tests
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Param group path string true "Group ID"
+// @Success 200 {object} models.ProblemTests
+// @Router /problemsedit/{id}/tests/groups/{group} [delete]
+func (ctrl *TestController) DeleteGroup(c *gin.Context) {
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+	g, ok := group(c, tests)
+	if !ok {
+		return
+	}
+	removed := *g
+	for i := range tests.Groups {
+		if tests.Groups[i].ID == removed.ID {
+			tests.Groups = append(tests.Groups[:i], tests.Groups[i+1:]...)
+			break
+		}
+	}
+	if err := ctrl.Repo.Save(context.Background(), tests); err != nil {
+		saveError(c, err)
+		return
+	}
+	if err := ctrl.Repo.DeleteFiles(context.Background(), removed.Tests); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tests)
+}
+
+// @Summary Reorder test groups
+// @Description Set the order groups are judged in; every group must be listed once
+// @Tags tests
+// @Accept json
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Param order body testOrderRequest true "Group IDs in the new order"
+// @Success 200 {object} models.ProblemTests
+// @Router /problemsedit/{id}/tests/order [put]
+func (ctrl *TestController) ReorderGroups(c *gin.Context) {
+	var req testOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+	if len(req.GroupIDs) != len(tests.Groups) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group_ids must list every group exactly once"})
+		return
+	}
+	ordered := make([]models.TestGroup, 0, len(tests.Groups))
+	seen := make(map[primitive.ObjectID]bool)
+	for _, hex := range req.GroupIDs {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil || seen[id] || tests.Group(id) == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "group_ids must list every group exactly once"})
+			return
+		}
+		seen[id] = true
+		ordered = append(ordered, *tests.Group(id))
+	}
+	tests.Groups = ordered
+	ctrl.save(c, tests)
+}
+
+// @Summary Upload tests
+// @Description Append tests to a group, either one "input"/"output" file pair or a zip "archive" of name.in and name.out (or name.ans) files. The "sample" field marks the new tests as samples.
+// @Tags tests
+// @Accept multipart/form-data
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Param group path string true "Group ID"
+// @Param input formData file false "Input file"
+// @Param output formData file false "Expected output file"
+// @Param archive formData file false "Zip archive of tests"
+// @Param name formData string false "Test name for a single pair"
+// @Param sample formData bool false "Mark as sample tests"
+// @Success 200 {object} models.ProblemTests
+// @Router /problemsedit/{id}/tests/groups/{group}/tests [post]
+func (ctrl *TestController) UploadTests(c *gin.Context) {
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+	g, ok := group(c, tests)
+	if !ok {
+		return
+	}
+	sample, _ := strconv.ParseBool(c.PostForm("sample"))
+
+	pairs, err := uploadedPairs(c, g)
+	if err == nil {
+		err = checkNames(g, pairs)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var added []models.TestCase
+	for _, p := range pairs {
+		tc, err := ctrl.store(p, sample)
+		if err != nil {
+			// don't leave the files of a half-finished upload behind
+			ctrl.Repo.DeleteFiles(context.Background(), added)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		added = append(added, tc)
+	}
+	g.Tests = append(g.Tests, added...)
+	if err := ctrl.Repo.Save(context.Background(), tests); err != nil {
+		ctrl.Repo.DeleteFiles(context.Background(), added)
+		saveError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tests)
+}
+
+// uploadedPairs reads the tests of an upload request to group g.
+func uploadedPairs(c *gin.Context, g *models.TestGroup) ([]testcase.Pair, error) {
+	if header, err := c.FormFile("archive"); err == nil {
+		if header.Size > maxArchiveSize {
+			return nil, errors.New("archive is too large")
+		}
+		data, err := readUpload(header, maxArchiveSize)
+		if err != nil {
+			return nil, err
+		}
+		return testcase.ReadZip(bytes.NewReader(data), int64(len(data)))
+	}
+
+	inHeader, err := c.FormFile("input")
+	if err != nil {
+		return nil, errors.New("either an archive or input and output files are required")
+	}
+	outHeader, err := c.FormFile("output")
+	if err != nil {
+		return nil, errors.New("either an archive or input and output files are required")
+	}
+	input, err := readUpload(inHeader, testcase.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	output, err := readUpload(outHeader, testcase.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+	name := c.PostForm("name")
+	if name == "" {
+		name = freeName(g)
+	}
+	return []testcase.Pair{{Name: name, Input: input, Output: output}}, nil
+}
+
+// nameTaken reports whether a test of g other than the one with id skip is
+// called name.
+func nameTaken(g *models.TestGroup, name string, skip primitive.ObjectID) bool {
+	for _, t := range g.Tests {
+		if t.Name == name && t.ID != skip {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNames returns an error if a test to be added to g has the name of
+// one already there. Names within pairs are unique already.
+func checkNames(g *models.TestGroup, pairs []testcase.Pair) error {
+	for _, p := range pairs {
+		if nameTaken(g, p.Name, primitive.NilObjectID) {
+			return fmt.Errorf("group already has a test named %q", p.Name)
+		}
+	}
+	return nil
+}
+
+// freeName returns the first number past the size of g that no test of g
+// is called, to name an unnamed upload.
+func freeName(g *models.TestGroup) string {
+	for n := len(g.Tests) + 1; ; n++ {
+		if name := strconv.Itoa(n); !nameTaken(g, name, primitive.NilObjectID) {
+			return name
+		}
+	}
+}
+
+func readUpload(header *multipart.FileHeader, limit int64) ([]byte, error) {
+	if header.Size > limit {
+		return nil, errors.New(header.Filename + " is too large")
+	}
+	f, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, limit))
+}
+
+// store uploads the files of a test to GridFS.
+func (ctrl *TestController) store(p testcase.Pair, sample bool) (models.TestCase, error) {
+	tc := models.TestCase{ID: primitive.NewObjectID(), Name: p.Name, Sample: sample}
+	var err error
+	tc.InputFileID, tc.InputSHA256, tc.InputSize, err = ctrl.Repo.UploadFile(context.Background(), p.Name+".in", bytes.NewReader(p.Input))
+	if err != nil {
+		return tc, err
+	}
+	tc.OutputFileID, tc.OutputSHA256, tc.OutputSize, err = ctrl.Repo.UploadFile(context.Background(), p.Name+".out", bytes.NewReader(p.Output))
+	if err != nil {
+		ctrl.Repo.DeleteFiles(context.Background(), []models.TestCase{{InputFileID: tc.InputFileID}})
+		return tc, err
+	}
+	return tc, nil
+}
+
+// testIndex returns the position of the :test path parameter in g.
+func testIndex(c *gin.Context, g *models.TestGroup) (int, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param("test"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid test ID"})
+		return 0, false
+	}
+	for i := range g.Tests {
+		if g.Tests[i].ID == id {
+			return i, true
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Test not found"})
+	return 0, false
+}
+
+// @Summary Update a test
+// @Description Rename a test or change whether it is a sample
+// @Tags tests
+// @Accept json
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Param group path string true "Group ID"
+// @Param test path string true "Test ID"
+// @Param body body testCaseRequest true "Fields to change"
+// @Success 200 {object} models.ProblemTests
+// @Router /problemsedit/{id}/tests/groups/{group}/tests/{test} [put]
+func (ctrl *TestController) UpdateTest(c *gin.Context) {
+	var req testCaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+	g, ok := group(c, tests)
+	if !ok {
+		return
+	}
+	i, ok := testIndex(c, g)
+	if !ok {
+		return
+	}
+	if req.Name != nil {
+		if *req.Name == "" || nameTaken(g, *req.Name, g.Tests[i].ID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Test names must be non-empty and unique in their group"})
+			return
+		}
+		g.Tests[i].Name = *req.Name
+	}
+	if req.Sample != nil {
+		g.Tests[i].Sample = *req.Sample
+	}
+	ctrl.save(c, tests)
+}
+
+// @Summary Delete a test
+// @Description Delete a test and its files
+// @Tags tests
+// @Produce json
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Param group path string true "Group ID"
+// @Param test path string true "Test ID"
+// @Success 200 {object} models.ProblemTests
+// @Router /problemsedit/{id}/tests/groups/{group}/tests/{test} [delete]
+func (ctrl *TestController) DeleteTest(c *gin.Context) {
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+	g, ok := group(c, tests)
+	if !ok {
+		return
+	}
+	i, ok := testIndex(c, g)
+	if !ok {
+		return
+	}
+	removed := g.Tests[i]
+	g.Tests = append(g.Tests[:i], g.Tests[i+1:]...)
+	if err := ctrl.Repo.Save(context.Background(), tests); err != nil {
+		saveError(c, err)
+		return
+	}
+	if err := ctrl.Repo.DeleteFiles(context.Background(), []models.TestCase{removed}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tests)
+}
+
+// @Summary Download a test file
+// @Description Download an input or expected output file of one of the problem's tests
+// @Tags tests
+// @Produce octet-stream
+// @Security Auth
+// @Param id path string true "Problem ID"
+// @Param file path string true "File ID"
+// @Success 200 {file} file
+// @Failure 404 {object} string "File not found"
+// @Router /problemsedit/{id}/tests/files/{file} [get]
+func (ctrl *TestController) DownloadFile(c *gin.Context) {
+	fileID, err := primitive.ObjectIDFromHex(c.Param("file"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file ID"})
+		return
+	}
+	tests, ok := ctrl.load(c)
+	if !ok {
+		return
+	}
+
+	// only serve files that belong to this problem
+	var name string
+	var size int64
+	for _, g := range tests.Groups {
+		for _, t := range g.Tests {
+			switch fileID {
+			case t.InputFileID:
+				name, size = t.Name+".in", t.InputSize
+			case t.OutputFileID:
+				name, size = t.Name+".out", t.OutputSize
+			}
+		}
+	}
+	if name == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	file, err := ctrl.Repo.OpenFile(context.Background(), fileID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	defer file.Close()
+	c.DataFromReader(http.StatusOK, size, "application/octet-stream", file, map[string]string{
+		"Content-Disposition": `attachment; filename="` + name + `"`,
+	})
+}
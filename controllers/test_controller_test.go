@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/testcase"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNamesAreUniqueInGroup(t *testing.T) {
+	first := primitive.NewObjectID()
+	g := &models.TestGroup{Tests: []models.TestCase{
+		{ID: first, Name: "1"},
+		{ID: primitive.NewObjectID(), Name: "3"},
+	}}
+	if err := checkNames(g, []testcase.Pair{{Name: "2"}, {Name: "3"}}); err == nil {
+		t.Error("upload reusing a name was accepted")
+	}
+	if err := checkNames(g, []testcase.Pair{{Name: "2"}}); err != nil {
+		t.Error(err)
+	}
+	if !nameTaken(g, "3", first) || nameTaken(g, "1", first) {
+		t.Error("renaming a test checked against the wrong tests")
+	}
+	if name := freeName(g); name != "4" {
+		t.Errorf("unnamed test called %q, want 4", name)
+	}
+}
@@ -0,0 +1,265 @@
+// Package grading runs the checkers and interactors of native problems.
+//
+// Both are C++17 programs built against testlib.h. They are compiled once
+// per distinct source and cached on disk, and report their verdict through
+// testlib's exit codes.
+package grading
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/sandbox"
+)
+
+// ErrCheckerFailed is returned when a checker or interactor does not
+// compile, crashes or reports that it failed itself. It means the problem
+// is broken, not the submission.
+var ErrCheckerFailed = errors.New("checker failed")
+
+// Exit codes of testlib programs.
+const (
+	exitOK     = 0
+	exitWA     = 1
+	exitPE     = 2
+	exitFail   = 3
+	exitPoints = 7
+)
+
+// maxMessage bounds the checker comment kept on a test result.
+const maxMessage = 1024
+
+// Result is a checker's judgement of one answer.
+type Result struct {
+	Verdict string
+	Message string
+}
+
+// Grader compiles and runs checkers.
+type Grader struct {
+	// CacheDir keeps compiled programs, named by the hash of their source.
+	CacheDir string
+	// TestlibDir is added to the include path; empty relies on testlib.h
+	// being installed system-wide.
+	TestlibDir    string
+	CompileLimits sandbox.Limits
+	RunLimits     sandbox.Limits
+	// Isolation is the user checkers are built and run as; like solutions,
+	// problem setters' programs are untrusted. It must not be the user
+	// solutions run as, since checkers read the expected answers.
+	Isolation *sandbox.Isolation
+
+	mu       sync.Mutex
+	building map[string]*sync.Mutex
+}
+
+// New creates a grader caching binaries under cacheDir.
+func New(cacheDir, testlibDir string) *Grader {
+	return &Grader{
+		CacheDir:   cacheDir,
+		TestlibDir: testlibDir,
+		CompileLimits: sandbox.Limits{
+			CPUTime:       time.Minute,
+			WallTime:      2 * time.Minute,
+			Memory:        1 << 30,
+			VirtualMemory: 4 << 30,
+			Output:        1 << 20,
+			Processes:     256,
+			FileSize:      256 << 20,
+		},
+		RunLimits: sandbox.Limits{
+			CPUTime:   10 * time.Second,
+			WallTime:  20 * time.Second,
+			Memory:    512 << 20,
+			Stack:     512 << 20,
+			Output:    1 << 20,
+			Processes: 256,
+			FileSize:  64 << 20,
+		},
+		building: make(map[string]*sync.Mutex),
+	}
+}
+
+// Compile returns the path of the compiled program, building it unless a
+// cached binary for the same source exists.
+func (g *Grader) Compile(ctx context.Context, prog models.Program) (string, error) {
+	sum := sha256.Sum256([]byte(prog.Source))
+	key := hex.EncodeToString(sum[:])
+	bin := filepath.Join(g.CacheDir, key)
+
+	// one build per source at a time; others wait for it
+	lock := g.lock(key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if _, err := os.Stat(bin); err == nil {
+		return bin, nil
+	}
+	if err := os.MkdirAll(g.CacheDir, 0o711); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(g.CacheDir, "build-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	if err := g.Isolation.Own(dir); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "checker.cpp"), []byte(prog.Source), 0o644); err != nil {
+		return "", err
+	}
+
+	args := []string{"g++", "-std=c++17", "-O2", "-pipe", "-o", "checker", "checker.cpp"}
+	if g.TestlibDir != "" {
+		args = append(args, "-I", g.TestlibDir)
+	}
+	res, err := sandbox.Run(ctx, sandbox.Cmd{
+		Args:      args,
+		Dir:       dir,
+		Env:       env(dir),
+		Limits:    g.CompileLimits,
+		Isolation: g.Isolation,
+	})
+	if err != nil {
+		return "", err
+	}
+	if res.Status != sandbox.OK {
+		return "", fmt.Errorf("%w: compilation: %s", ErrCheckerFailed, truncate(string(res.Stderr)))
+	}
+	if err := install(filepath.Join(dir, "checker"), bin); err != nil {
+		return "", err
+	}
+	return bin, nil
+}
+
+// install copies a built program to path as a file of our own, so the
+// isolated user it was built as cannot change it afterwards. Other users
+// may run it but not read it. The copy is renamed into place, which is
+// atomic: a half-written binary is never picked up.
+func install(built, path string) error {
+	data, err := os.ReadFile(built)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o711); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// env is the whole environment of checkers and interactors: nothing of the
+// server's, which holds secrets, is passed on.
+func env(dir string) []string {
+	return []string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir}
+}
+
+// workDir creates a directory for one run of a checker or interactor
+// holding files. Only the checker's user may enter it: the files include
+// the expected answer, which solutions must never be able to read.
+func (g *Grader) workDir(pattern string, files map[string][]byte) (string, error) {
+	dir, err := os.MkdirTemp("", pattern)
+	if err != nil {
+		return "", err
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err = os.WriteFile(path, data, 0o600); err != nil {
+			break
+		}
+		if err = g.Isolation.Own(path); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = g.Isolation.Own(dir)
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+func (g *Grader) lock(key string) *sync.Mutex {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.building == nil {
+		g.building = make(map[string]*sync.Mutex)
+	}
+	l, ok := g.building[key]
+	if !ok {
+		l = &sync.Mutex{}
+		g.building[key] = l
+	}
+	return l
+}
+
+// Check runs checker on a contestant's output, testlib style:
+// "checker input output answer".
+func (g *Grader) Check(ctx context.Context, checker models.Program, input, output, answer []byte) (*Result, error) {
+	bin, err := g.Compile(ctx, checker)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := g.workDir("check-", map[string][]byte{"input.txt": input, "output.txt": output, "answer.txt": answer})
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	res, err := sandbox.Run(ctx, sandbox.Cmd{
+		Args:      []string{bin, "input.txt", "output.txt", "answer.txt"},
+		Dir:       dir,
+		Env:       env(dir),
+		Limits:    g.RunLimits,
+		Isolation: g.Isolation,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return verdict(res, "checker")
+}
+
+// verdict maps how a testlib program exited to a result.
+func verdict(res *sandbox.Result, what string) (*Result, error) {
+	msg := truncate(strings.TrimSpace(string(res.Stderr)))
+	// non-zero exits are verdicts; being killed or hitting a limit is not
+	if (res.Status != sandbox.OK && res.Status != sandbox.RuntimeError) || res.Signal != 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrCheckerFailed, what, res.Status)
+	}
+	switch res.ExitCode {
+	case exitOK:
+		return &Result{Verdict: models.VerdictAccepted, Message: msg}, nil
+	case exitWA, exitPE:
+		return &Result{Verdict: models.VerdictWrongAnswer, Message: msg}, nil
+	case exitPoints:
+		// partial scores are not supported; anything short of OK fails
+		return &Result{Verdict: models.VerdictWrongAnswer, Message: msg}, nil
+	case exitFail:
+		return nil, fmt.Errorf("%w: %s: %s", ErrCheckerFailed, what, msg)
+	default:
+		return nil, fmt.Errorf("%w: %s exited with %d: %s", ErrCheckerFailed, what, res.ExitCode, msg)
+	}
+}
+
+func truncate(s string) string {
+	if len(s) > maxMessage {
+		return s[:maxMessage] + "..."
+	}
+	return s
+}
@@ -0,0 +1,225 @@
+package grading
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/sandbox"
+)
+
+// compareChecker accepts an output holding the same integer as the answer.
+const compareChecker = `#include "testlib.h"
+int main(int argc, char *argv[]) {
+    registerTestlibCmd(argc, argv);
+    long long want = ans.readLong(), got = ouf.readLong();
+    if (got != want)
+        quitf(_wa, "expected %lld, found %lld", want, got);
+    quitf(_ok, "%lld", got);
+}
+`
+
+// newGrader returns a grader with testdata/testlib.h on its include path,
+// running checkers as nobody where it can. Everything lives in a directory
+// other users can reach, unlike the tree of t.TempDir.
+func newGrader(t *testing.T) *Grader {
+	t.Helper()
+	if _, err := exec.LookPath("g++"); err != nil {
+		t.Skip("g++ is not installed")
+	}
+	dir, err := os.MkdirTemp("", "grading-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	if err := os.Chmod(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	header, err := os.ReadFile(filepath.Join("testdata", "testlib.h"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "testlib.h"), header, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := New(filepath.Join(dir, "cache"), dir)
+	if os.Getuid() == 0 {
+		if iso, err := sandbox.LookupUser("nobody"); err == nil {
+			g.Isolation = iso
+		}
+	}
+	return g
+}
+
+func TestCheck(t *testing.T) {
+	g := newGrader(t)
+	tests := []struct {
+		name    string
+		source  string
+		output  string
+		answer  string
+		verdict string
+		message string
+		fail    bool
+	}{
+		{name: "ok", source: compareChecker, output: "3\n", answer: "3\n", verdict: models.VerdictAccepted, message: "3"},
+		{name: "wrong answer", source: compareChecker, output: "4\n", answer: "3\n", verdict: models.VerdictWrongAnswer, message: "expected 3, found 4"},
+		{name: "presentation error", source: compareChecker, output: "three\n", answer: "3\n", verdict: models.VerdictWrongAnswer, message: "expected an integer"},
+		{name: "checker fails", source: compareChecker, output: "3\n", answer: "three\n", fail: true},
+		{name: "checker crashes", source: "#include <cstdlib>\nint main() { abort(); }\n", output: "3\n", answer: "3\n", fail: true},
+		{name: "unknown exit code", source: "int main() { return 42; }\n", output: "3\n", answer: "3\n", fail: true},
+		{name: "does not compile", source: "int main() { return x; }\n", output: "3\n", answer: "3\n", fail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := g.Check(context.Background(), models.Program{Source: tt.source}, []byte("\n"), []byte(tt.output), []byte(tt.answer))
+			if tt.fail {
+				if !errors.Is(err, ErrCheckerFailed) {
+					t.Fatalf("got %+v, %v; want ErrCheckerFailed", res, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Verdict != tt.verdict || res.Message != tt.message {
+				t.Errorf("got %s %q, want %s %q", res.Verdict, res.Message, tt.verdict, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckerDoesNotSeeOurEnvironment(t *testing.T) {
+	g := newGrader(t)
+	t.Setenv("SESSION_KEYS", "k1:secret")
+	source := `#include <cstdio>
+#include <cstdlib>
+int main() {
+    const char *keys = getenv("SESSION_KEYS");
+    fprintf(stderr, "%s", keys ? keys : "none");
+    return 0;
+}
+`
+	res, err := g.Check(context.Background(), models.Program{Source: source}, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Message != "none" {
+		t.Errorf("checker saw SESSION_KEYS: %q", res.Message)
+	}
+}
+
+func TestCheckerCannotReadOurFiles(t *testing.T) {
+	g := newGrader(t)
+	if g.Isolation == nil {
+		t.Skip("isolation needs root and a nobody user")
+	}
+	secret := filepath.Join(g.TestlibDir, "secret")
+	if err := os.WriteFile(secret, []byte("MONGO_URI=x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	source := `#include <cstdio>
+int main() {
+    FILE *f = fopen("` + secret + `", "r");
+    fprintf(stderr, "%s", f ? "read" : "denied");
+    return 0;
+}
+`
+	res, err := g.Check(context.Background(), models.Program{Source: source}, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res.Message, "denied") {
+		t.Errorf("checker could open our file: %q", res.Message)
+	}
+}
+
+// solutionUser returns a user other than the checker's to run solutions
+// as, or nil when checkers are not isolated either.
+func solutionUser(t *testing.T, g *Grader) *sandbox.Isolation {
+	t.Helper()
+	if g.Isolation == nil {
+		return nil
+	}
+	iso, err := sandbox.LookupUser("daemon")
+	if err != nil {
+		t.Skip("needs a daemon user to run solutions as")
+	}
+	return iso
+}
+
+func TestSolutionsCannotReadAnswers(t *testing.T) {
+	g := newGrader(t)
+	if g.Isolation == nil {
+		t.Skip("isolation needs root and a nobody user")
+	}
+	dir, err := g.workDir("check-", map[string][]byte{"answer.txt": []byte("42")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	res, err := sandbox.Run(context.Background(), sandbox.Cmd{
+		Args:      []string{"cat", filepath.Join(dir, "answer.txt")},
+		Dir:       "/",
+		Env:       []string{"PATH=" + os.Getenv("PATH")},
+		Isolation: solutionUser(t, g),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode == 0 || strings.Contains(string(res.Stdout), "42") {
+		t.Errorf("a solution read the answer: %q", res.Stdout)
+	}
+}
+
+// doubler asks for twice the number in the input and records the reply.
+const doubler = `#include <cstdio>
+int main(int argc, char *argv[]) {
+    long long n, got;
+    FILE *in = fopen(argv[1], "r"), *out = fopen(argv[2], "w");
+    if (!in || !out || fscanf(in, "%lld", &n) != 1) return 3;
+    printf("%lld\n", n);
+    fflush(stdout);
+    if (scanf("%lld", &got) != 1) return 2;
+    fprintf(out, "%lld\n", got);
+    return got == 2 * n ? 0 : 1;
+}
+`
+
+func TestInteract(t *testing.T) {
+	g := newGrader(t)
+	tests := []struct {
+		name     string
+		solution string
+		verdict  string
+	}{
+		{"ok", `read n; echo $((n * 2))`, models.VerdictAccepted},
+		{"wrong answer", `read n; echo $n`, models.VerdictWrongAnswer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			solution := sandbox.Cmd{
+				Args:      []string{"sh", "-c", tt.solution},
+				Dir:       g.TestlibDir,
+				Isolation: solutionUser(t, g),
+			}
+			res, err := g.Interact(context.Background(), models.Program{Source: doubler}, solution, []byte("21\n"), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Result == nil || res.Result.Verdict != tt.verdict {
+				t.Fatalf("got %+v, want %s", res.Result, tt.verdict)
+			}
+			if res.Solution.Status != sandbox.OK || len(res.Output) == 0 {
+				t.Errorf("solution %s, interactor output %q", res.Solution.Status, res.Output)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package grading
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/sandbox"
+)
+
+// Interaction is the outcome of running a solution against an interactor.
+type Interaction struct {
+	// Solution is how the solution's run ended.
+	Solution *sandbox.Result
+	// Result is the interactor's verdict; nil if the interactor failed
+	// after the solution did.
+	Result *Result
+	// Output is what the interactor wrote to its output file, for a
+	// checker to inspect.
+	Output []byte
+}
+
+// Interact runs solution with its standard input and output connected to
+// the interactor, testlib style: "interactor input output answer".
+func (g *Grader) Interact(ctx context.Context, interactor models.Program, solution sandbox.Cmd, input, answer []byte) (*Interaction, error) {
+	bin, err := g.Compile(ctx, interactor)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := g.workDir("interact-", map[string][]byte{"input.txt": input, "output.txt": nil, "answer.txt": answer})
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	outputPath := filepath.Join(dir, "output.txt")
+
+	// one pipe carries the solution's output, the other the interactor's replies
+	fromSolution, toInteractor, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	fromInteractor, toSolution, err := os.Pipe()
+	if err != nil {
+		fromSolution.Close()
+		toInteractor.Close()
+		return nil, err
+	}
+
+	solution.Stdin = fromInteractor
+	solution.Stdout = toInteractor
+	limits := g.RunLimits
+	// the interactor waits on the solution, so it may take as long
+	if solution.Limits.WallTime > limits.WallTime {
+		limits.WallTime = solution.Limits.WallTime
+	}
+	interactorCmd := sandbox.Cmd{
+		Args:      []string{bin, "input.txt", "output.txt", "answer.txt"},
+		Dir:       dir,
+		Env:       env(dir),
+		Stdin:     fromSolution,
+		Stdout:    toSolution,
+		Limits:    limits,
+		Isolation: g.Isolation,
+	}
+
+	// Each side's pipe ends are closed once it exits, so the other side
+	// sees end of file instead of blocking until its time limit.
+	var wg sync.WaitGroup
+	var solRes, intRes *sandbox.Result
+	var solErr, intErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		solRes, solErr = sandbox.Run(ctx, solution)
+		fromInteractor.Close()
+		toInteractor.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		intRes, intErr = sandbox.Run(ctx, interactorCmd)
+		fromSolution.Close()
+		toSolution.Close()
+	}()
+	wg.Wait()
+	if solErr != nil {
+		return nil, solErr
+	}
+	if intErr != nil {
+		return nil, intErr
+	}
+
+	// A solution that dies can take the interactor down with it (SIGPIPE);
+	// the solution's own failure is the verdict then.
+	result, err := verdict(intRes, "interactor")
+	if err != nil && solRes.Status == sandbox.OK {
+		return nil, err
+	}
+	output, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Interaction{Solution: solRes, Result: result, Output: output}, nil
+}
@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/AbenezerWork/AASTU-CPC/codeforces"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+)
+
+// Codeforces imports problems from Codeforces into our catalog.
+type Codeforces struct {
+	Client   *codeforces.Client
+	Probrepo *repository.ProblemRepository
+	Setrepo  *repository.ProblemSetRepository
+}
+
+// NewCodeforces creates a Codeforces importer.
+func NewCodeforces(client *codeforces.Client, pr *repository.ProblemRepository, psr *repository.ProblemSetRepository) *Codeforces {
+	return &Codeforces{
+		Client:   client,
+		Probrepo: pr,
+		Setrepo:  psr,
+	}
+}
+
+// ProblemsetQuery selects which problemset problems to import. Zero ratings
+// mean no bound; unrated problems are skipped when MinRating is set.
+type ProblemsetQuery struct {
+	Tags      []string `json:"tags"`
+	MinRating int      `json:"min_rating"`
+	MaxRating int      `json:"max_rating"`
+}
+
+// Result summarizes an import.
+type Result struct {
+	Fetched  int   `json:"fetched"`
+	Matched  int   `json:"matched"`
+	Inserted int64 `json:"inserted"`
+	Updated  int64 `json:"updated"`
+}
+
+// ImportProblemset upserts the problemset problems matching q.
+func (i *Codeforces) ImportProblemset(ctx context.Context, q ProblemsetQuery) (*Result, error) {
+	problemset, err := i.Client.ProblemsetProblems(ctx, q.Tags...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Fetched: len(problemset.Problems)}
+	var problems []models.Problem
+	for _, p := range problemset.Problems {
+		if p.ContestID == 0 {
+			// problems of the old acm.sgu.ru archive have no contest
+			continue
+		}
+		if q.MinRating > 0 && p.Rating < q.MinRating {
+			continue
+		}
+		if q.MaxRating > 0 && p.Rating > q.MaxRating {
+			continue
+		}
+		problems = append(problems, problemFromCodeforces(p))
+	}
+	result.Matched = len(problems)
+
+	result.Inserted, result.Updated, err = i.Probrepo.UpsertMany(ctx, problems)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// problemFromCodeforces maps an API problem to our model.
+func problemFromCodeforces(p codeforces.Problem) models.Problem {
+	tags := p.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return models.Problem{
+		Title:            p.Name,
+		ProblemStatement: problemURL(p),
+		Source:           "codeforces",
+		Difficulty:       p.Rating,
+		ContestID:        strconv.Itoa(p.ContestID),
+		Index:            p.Index,
+		Tags:             tags,
+	}
+}
+
+func problemURL(p codeforces.Problem) string {
+	if p.ContestID >= 100000 {
+		return fmt.Sprintf("https://codeforces.com/gym/%d/problem/%s", p.ContestID, p.Index)
+	}
+	return fmt.Sprintf("https://codeforces.com/problemset/problem/%d/%s", p.ContestID, p.Index)
+}
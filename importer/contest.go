@@ -0,0 +1,106 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ContestQuery describes a contest or gym to import as a problem set.
+type ContestQuery struct {
+	ContestID int      `json:"contest_id"`
+	Division  string   `json:"division"`
+	Tags      []string `json:"tags"`
+}
+
+// ImportContest upserts every problem of a Codeforces contest or gym and a
+// problem set listing them in index order. Gym and mashup ids (100000 and
+// above) may need the client to have API credentials with access to them.
+func (i *Codeforces) ImportContest(ctx context.Context, q ContestQuery) (*models.ProblemSet, *Result, error) {
+	standings, err := i.Client.ContestStandings(ctx, q.ContestID, 1, 1, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	apiProblems := standings.Problems
+	sort.SliceStable(apiProblems, func(a, b int) bool {
+		return lessIndex(apiProblems[a].Index, apiProblems[b].Index)
+	})
+
+	problems := make([]models.Problem, 0, len(apiProblems))
+	for _, p := range apiProblems {
+		if p.ContestID == 0 {
+			p.ContestID = q.ContestID
+		}
+		problems = append(problems, problemFromCodeforces(p))
+	}
+
+	result := &Result{Fetched: len(apiProblems), Matched: len(problems)}
+	result.Inserted, result.Updated, err = i.Probrepo.UpsertMany(ctx, problems)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	contestID := strconv.Itoa(q.ContestID)
+	stored, err := i.Probrepo.GetByContest(ctx, "codeforces", contestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	idByIndex := make(map[string]primitive.ObjectID, len(stored))
+	for _, p := range stored {
+		idByIndex[p.Index] = p.ID
+	}
+
+	set := &models.ProblemSet{
+		Title:     standings.Contest.Name,
+		Source:    "codeforces",
+		ContestID: contestID,
+		Division:  q.Division,
+		Tags:      q.Tags,
+		CreatedAt: time.Now(),
+	}
+	if set.Tags == nil {
+		set.Tags = []string{}
+	}
+	for _, p := range problems {
+		id, ok := idByIndex[p.Index]
+		if !ok {
+			return nil, nil, fmt.Errorf("problem %s%s missing after import", contestID, p.Index)
+		}
+		set.ProblemIDs = append(set.ProblemIDs, id)
+	}
+
+	set, err = i.Setrepo.UpsertByContest(ctx, set)
+	if err != nil {
+		return nil, nil, err
+	}
+	return set, result, nil
+}
+
+// lessIndex orders problem indexes the way Codeforces lists them:
+// A < B < ... < Z, with sub-problems such as C1 < C2 < C10 after their letter.
+func lessIndex(a, b string) bool {
+	la, na := splitIndex(a)
+	lb, nb := splitIndex(b)
+	if la != lb {
+		if len(la) != len(lb) {
+			return len(la) < len(lb)
+		}
+		return la < lb
+	}
+	return na < nb
+}
+
+func splitIndex(index string) (string, int) {
+	i := 0
+	for i < len(index) && (index[i] < '0' || index[i] > '9') {
+		i++
+	}
+	n, _ := strconv.Atoi(index[i:])
+	return index[:i], n
+}
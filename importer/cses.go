@@ -0,0 +1,74 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/scrape"
+	"golang.org/x/net/html"
+)
+
+// CSES imports the CSES problem set task list.
+type CSES struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	Probrepo   *repository.ProblemRepository
+}
+
+// NewCSES creates a CSES importer reading the list from baseURL, e.g.
+// "https://cses.fi".
+func NewCSES(baseURL string, pr *repository.ProblemRepository) *CSES {
+	return &CSES{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Probrepo:   pr,
+	}
+}
+
+// Import upserts every task of the problem set, tagged with its category.
+func (i *CSES) Import(ctx context.Context) (*Result, error) {
+	doc, err := scrape.Fetch(ctx, i.HTTPClient, i.BaseURL+"/problemset/list/")
+	if err != nil {
+		return nil, err
+	}
+
+	problems := csesTasks(doc, i.BaseURL)
+	result := &Result{Fetched: len(problems), Matched: len(problems)}
+	result.Inserted, result.Updated, err = i.Probrepo.UpsertMany(ctx, problems)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// csesTasks reads the task list, where each category is an <h2> followed by
+// a list of links to /problemset/task/<id>.
+func csesTasks(doc *html.Node, baseURL string) []models.Problem {
+	var problems []models.Problem
+	category := ""
+	scrape.Walk(doc, func(n *html.Node) {
+		switch {
+		case scrape.IsElement(n, "h2"):
+			category = scrape.Text(n)
+		case scrape.IsElement(n, "a"):
+			href := strings.TrimRight(scrape.Attr(n, "href"), "/")
+			id, ok := strings.CutPrefix(href, "/problemset/task/")
+			if !ok || id == "" || category == "" || category == "General" {
+				return
+			}
+			problems = append(problems, models.Problem{
+				Title:            scrape.Text(n),
+				ProblemStatement: baseURL + href,
+				Source:           "cses",
+				ContestID:        "problemset",
+				Index:            id,
+				Tags:             []string{category},
+			})
+		}
+	})
+	return problems
+}
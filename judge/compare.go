@@ -0,0 +1,29 @@
+package judge
+
+import "bytes"
+
+// Equal compares program output with the expected answer line by line,
+// ignoring trailing whitespace on each line and trailing blank lines.
+func Equal(output, answer []byte) bool {
+	a, b := lines(output), lines(answer)
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func lines(data []byte) [][]byte {
+	split := bytes.Split(data, []byte("\n"))
+	for i := range split {
+		split[i] = bytes.TrimRight(split[i], " \t\r")
+	}
+	for len(split) > 0 && len(split[len(split)-1]) == 0 {
+		split = split[:len(split)-1]
+	}
+	return split
+}
@@ -0,0 +1,380 @@
+// Package judge compiles native submissions and runs them against the
+// problem's tests in the sandbox.
+package judge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/grading"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/sandbox"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrUnknownLanguage is returned for a submission in a language the
+	// judge does not support.
+	ErrUnknownLanguage = errors.New("unsupported language")
+	// ErrNoTests is returned for a problem without test data.
+	ErrNoTests = errors.New("problem has no tests")
+)
+
+// Defaults for problems that don't set their own limits.
+const (
+	DefaultTimeLimit   = 2000 // milliseconds
+	DefaultMemoryLimit = 256  // megabytes
+	DefaultOutputLimit = 64   // megabytes
+)
+
+// Maxima of problem limits, whoever set them. Larger values are lowered to
+// these, so that no problem ties up a worker or the host's memory.
+const (
+	MaxTimeLimit   = 10000 // milliseconds
+	MaxMemoryLimit = 1024  // megabytes
+	MaxOutputLimit = 256   // megabytes
+)
+
+// maxCompileOutput bounds the compiler messages kept on a submission.
+const maxCompileOutput = 16 << 10
+
+// Judge grades submissions to native problems.
+type Judge struct {
+	Tests  *repository.TestRepository
+	Grader *grading.Grader
+	// WorkDir holds a temporary directory per submission being judged, and
+	// the languages' caches.
+	WorkDir string
+	// CompileLimits bounds the compile step of every language.
+	CompileLimits sandbox.Limits
+	// Isolation is the user compiles and runs happen as. It must be set in
+	// production: without it submissions run as the judge's own user.
+	Isolation *sandbox.Isolation
+
+	mu       sync.Mutex
+	prepared map[string]bool
+}
+
+// New creates a judge working under workDir.
+func New(tests *repository.TestRepository, grader *grading.Grader, workDir string) *Judge {
+	return &Judge{
+		Tests:   tests,
+		Grader:  grader,
+		WorkDir: workDir,
+		CompileLimits: sandbox.Limits{
+			CPUTime:  30 * time.Second,
+			WallTime: time.Minute,
+			Memory:   1 << 30,
+			// compilers are native programs or runtimes that reserve more
+			// than they use; this only stops runaway allocations
+			VirtualMemory: 4 << 30,
+			Output:        1 << 20,
+			Processes:     maxProcesses,
+			FileSize:      256 << 20,
+		},
+		prepared: make(map[string]bool),
+	}
+}
+
+// maxProcesses caps processes and threads per run. The limit counts all
+// runs of the isolated user at once, so it leaves room for several
+// workers and for runtimes with many threads like the JVM.
+const maxProcesses = 256
+
+// Result is the judgement of a submission.
+type Result struct {
+	Verdict       string
+	CompileOutput string
+	Tests         []models.TestResult
+}
+
+// Progress is told how many tests a judgement will run once the submission
+// compiled, and about every test as it finishes. A group that fails stops
+// early, so fewer than total tests may be reported.
+type Progress interface {
+	Start(total int)
+	Test(result models.TestResult)
+}
+
+// Judge compiles the submission and runs it on every test group in order.
+// A group stops at its first failing test; the overall verdict is that of
+// the first failing test, or AC. progress may be nil.
+func (j *Judge) Judge(ctx context.Context, problem models.Problem, submission models.Submission, progress Progress) (*Result, error) {
+	lang, ok := Lookup(submission.Language)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLanguage, submission.Language)
+	}
+	tests, err := j.Tests.Get(ctx, problem.ID)
+	if err != nil {
+		return nil, err
+	}
+	if countTests(tests) == 0 {
+		return nil, ErrNoTests
+	}
+
+	if err := os.MkdirAll(j.WorkDir, 0o755); err != nil {
+		return nil, err
+	}
+	cache, err := j.prepare(ctx, lang)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(j.WorkDir, "submission-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	// the compiler writes its output next to the source
+	if err := j.Isolation.Own(dir); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, lang.Source), []byte(submission.Code), 0o644); err != nil {
+		return nil, err
+	}
+
+	env := append([]string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir}, lang.env(cache)...)
+	if lang.Compile != nil {
+		compiled, err := sandbox.Run(ctx, sandbox.Cmd{Args: lang.Compile, Dir: dir, Env: env, Limits: j.CompileLimits, Isolation: j.Isolation})
+		if err != nil {
+			return nil, err
+		}
+		if compiled.Status != sandbox.OK {
+			return &Result{
+				Verdict:       models.VerdictCompilationError,
+				CompileOutput: compileOutput(compiled),
+			}, nil
+		}
+	}
+
+	if progress != nil {
+		progress.Start(countTests(tests))
+	}
+	limits := problemLimits(problem, lang)
+	run := sandbox.Cmd{Args: lang.runArgs(memoryMB(problem)), Dir: dir, Env: env, Limits: limits, Isolation: j.Isolation}
+	result := &Result{Verdict: models.VerdictAccepted}
+	for _, group := range tests.Groups {
+		for _, test := range group.Tests {
+			tr, err := j.runTest(ctx, problem, run, test)
+			if err != nil {
+				return nil, err
+			}
+			tr.GroupID = group.ID
+			result.Tests = append(result.Tests, tr)
+			if progress != nil {
+				progress.Test(tr)
+			}
+			if tr.Verdict != models.VerdictAccepted {
+				if result.Verdict == models.VerdictAccepted {
+					result.Verdict = tr.Verdict
+				}
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// prepare returns the cache directory of lang, running its Prepare command
+// the first time. The directory is ours: the isolated user can read it but
+// not write to it.
+func (j *Judge) prepare(ctx context.Context, lang Language) (string, error) {
+	cache := filepath.Join(j.WorkDir, "cache", lang.ID)
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.prepared[lang.ID] {
+		return cache, nil
+	}
+	if err := os.MkdirAll(cache, 0o755); err != nil {
+		return "", err
+	}
+	if lang.Prepare != nil {
+		env := append([]string{"PATH=" + os.Getenv("PATH"), "HOME=" + cache}, lang.env(cache)...)
+		res, err := sandbox.Run(ctx, sandbox.Cmd{Args: lang.Prepare, Dir: cache, Env: env})
+		if err != nil {
+			return "", err
+		}
+		if res.Status != sandbox.OK {
+			return "", fmt.Errorf("judge: preparing %s: %s", lang.ID, compileOutput(res))
+		}
+		if err := readOnly(cache); err != nil {
+			return "", err
+		}
+	}
+	if j.prepared == nil {
+		j.prepared = make(map[string]bool)
+	}
+	j.prepared[lang.ID] = true
+	return cache, nil
+}
+
+// readOnly takes write permission on everything under dir from all but its
+// owner, whatever the umask was when it was written.
+func readOnly(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if mode := info.Mode().Perm(); mode&0o022 != 0 {
+			return os.Chmod(path, mode&^0o022)
+		}
+		return nil
+	})
+}
+
+// runTest runs the compiled program on one test.
+func (j *Judge) runTest(ctx context.Context, problem models.Problem, run sandbox.Cmd, test models.TestCase) (models.TestResult, error) {
+	tr := models.TestResult{TestID: test.ID}
+	input, err := j.readFile(ctx, test.InputFileID)
+	if err != nil {
+		return tr, err
+	}
+	answer, err := j.readFile(ctx, test.OutputFileID)
+	if err != nil {
+		return tr, err
+	}
+
+	var out *sandbox.Result
+	var output []byte
+	if problem.Interactor != nil {
+		inter, err := j.Grader.Interact(ctx, *problem.Interactor, run, input, answer)
+		if err != nil {
+			return tr, err
+		}
+		out, output = inter.Solution, inter.Output
+		// the interactor noticing a wrong reply beats the solution then
+		// failing on its missing answer
+		if inter.Result != nil && inter.Result.Verdict != models.VerdictAccepted {
+			setUsage(&tr, out)
+			tr.Verdict, tr.Message = inter.Result.Verdict, inter.Result.Message
+			return tr, nil
+		}
+	} else {
+		run.Stdin = bytes.NewReader(input)
+		out, err = sandbox.Run(ctx, run)
+		if err != nil {
+			return tr, err
+		}
+		output = out.Stdout
+	}
+	setUsage(&tr, out)
+
+	switch out.Status {
+	case sandbox.TimeLimit:
+		tr.Verdict = models.VerdictTimeLimitExceeded
+	case sandbox.MemoryLimit:
+		tr.Verdict = models.VerdictMemoryLimitExceeded
+	case sandbox.OutputLimit:
+		tr.Verdict = models.VerdictRuntimeError
+		tr.Message = "output limit exceeded"
+	case sandbox.RuntimeError:
+		tr.Verdict = models.VerdictRuntimeError
+		tr.Message = exitMessage(out)
+	default:
+		if problem.Checker != nil {
+			res, err := j.Grader.Check(ctx, *problem.Checker, input, output, answer)
+			if err != nil {
+				return tr, err
+			}
+			tr.Verdict, tr.Message = res.Verdict, res.Message
+		} else if problem.Interactor != nil || Equal(output, answer) {
+			// without a checker the interactor's word is final
+			tr.Verdict = models.VerdictAccepted
+		} else {
+			tr.Verdict = models.VerdictWrongAnswer
+		}
+	}
+	return tr, nil
+}
+
+func setUsage(tr *models.TestResult, out *sandbox.Result) {
+	tr.Time = out.CPUTime.Milliseconds()
+	tr.Memory = out.Memory / 1024
+}
+
+func (j *Judge) readFile(ctx context.Context, id primitive.ObjectID) ([]byte, error) {
+	f, err := j.Tests.OpenFile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func countTests(tests *models.ProblemTests) int {
+	n := 0
+	for _, g := range tests.Groups {
+		n += len(g.Tests)
+	}
+	return n
+}
+
+func memoryMB(problem models.Problem) int {
+	if problem.MemoryLimit > 0 {
+		return min(problem.MemoryLimit, MaxMemoryLimit)
+	}
+	return DefaultMemoryLimit
+}
+
+// problemLimits returns the sandbox limits for running a solution.
+func problemLimits(problem models.Problem, lang Language) sandbox.Limits {
+	timeLimit := time.Duration(DefaultTimeLimit) * time.Millisecond
+	if problem.TimeLimit > 0 {
+		timeLimit = time.Duration(min(problem.TimeLimit, MaxTimeLimit)) * time.Millisecond
+	}
+	output := int64(DefaultOutputLimit)
+	if problem.OutputLimit > 0 {
+		output = int64(min(problem.OutputLimit, MaxOutputLimit))
+	}
+	memory := int64(memoryMB(problem)) << 20
+	var virtual int64
+	if lang.Headroom > 0 {
+		virtual = memory + lang.Headroom
+	}
+	return sandbox.Limits{
+		CPUTime: timeLimit,
+		// leave room for waiting on I/O, but not for sleeping forever
+		WallTime:      2*timeLimit + time.Second,
+		Memory:        memory,
+		AddressSpace:  lang.AddressSpace,
+		VirtualMemory: virtual,
+		Stack:         memory,
+		Output:        output << 20,
+		Processes:     maxProcesses,
+		FileSize:      output << 20,
+	}
+}
+
+func compileOutput(r *sandbox.Result) string {
+	var msg string
+	switch r.Status {
+	case sandbox.TimeLimit:
+		msg = "compilation timed out\n"
+	case sandbox.MemoryLimit:
+		msg = "compilation used too much memory\n"
+	}
+	msg += string(r.Stderr) + string(r.Stdout)
+	if len(msg) > maxCompileOutput {
+		msg = msg[:maxCompileOutput] + "\n[truncated]"
+	}
+	return strings.TrimSpace(msg)
+}
+
+func exitMessage(r *sandbox.Result) string {
+	if r.Signal != 0 {
+		return "killed by " + r.Signal.String()
+	}
+	return fmt.Sprintf("exit code %d", r.ExitCode)
+}
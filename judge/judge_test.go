@@ -0,0 +1,23 @@
+package judge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+func TestProblemLimitsAreCapped(t *testing.T) {
+	lang, _ := Lookup("cpp17")
+	problem := models.Problem{TimeLimit: 1 << 30, MemoryLimit: 1 << 20, OutputLimit: 1 << 20}
+	limits := problemLimits(problem, lang)
+	if limits.CPUTime != MaxTimeLimit*time.Millisecond {
+		t.Errorf("time limit %v, want %v", limits.CPUTime, MaxTimeLimit*time.Millisecond)
+	}
+	if limits.Memory != MaxMemoryLimit<<20 || memoryMB(problem) != MaxMemoryLimit {
+		t.Errorf("memory limit %d, want %d", limits.Memory, MaxMemoryLimit<<20)
+	}
+	if limits.Output != MaxOutputLimit<<20 {
+		t.Errorf("output limit %d, want %d", limits.Output, MaxOutputLimit<<20)
+	}
+}
@@ -0,0 +1,12 @@
+package judge
+
+func init() {
+	Register(Language{
+		ID:           "cpp17",
+		Name:         "C++17 (g++)",
+		Source:       "main.cpp",
+		Compile:      []string{"g++", "-std=c++17", "-O2", "-pipe", "-o", "main", "main.cpp"},
+		Run:          []string{"./main"},
+		AddressSpace: true,
+	})
+}
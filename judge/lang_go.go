@@ -0,0 +1,18 @@
+package judge
+
+func init() {
+	// the runtime reserves address space well beyond what it uses, so the
+	// cap leaves headroom and GOMEMLIMIT makes the collector keep the heap
+	// under the limit
+	Register(Language{
+		ID:       "go",
+		Name:     "Go",
+		Source:   "main.go",
+		Compile:  []string{"go", "build", "-o", "main", "main.go"},
+		Run:      []string{"env", "GOMEMLIMIT={memory}MiB", "./main"},
+		Headroom: 2 << 30,
+		Env:      []string{"GOCACHE={cache}", "GO111MODULE=off", "CGO_ENABLED=0"},
+		// the standard library, built once instead of by every submission
+		Prepare: []string{"go", "build", "std"},
+	})
+}
@@ -0,0 +1,14 @@
+package judge
+
+func init() {
+	// the JVM reserves its heap up front, so memory is capped with -Xmx
+	// rather than the address space; javac's heap is kept well inside the
+	// compile step's cap
+	Register(Language{
+		ID:      "java",
+		Name:    "Java",
+		Source:  "Main.java",
+		Compile: []string{"javac", "-J-Xmx512m", "-J-XX:+UseSerialGC", "-encoding", "UTF-8", "Main.java"},
+		Run:     []string{"java", "-XX:+UseSerialGC", "-Xss64m", "-Xmx{memory}m", "Main"},
+	})
+}
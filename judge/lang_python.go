@@ -0,0 +1,13 @@
+package judge
+
+func init() {
+	Register(Language{
+		ID:     "python3",
+		Name:   "Python 3",
+		Source: "main.py",
+		// byte-compiling reports syntax errors as compilation errors
+		Compile:      []string{"python3", "-m", "py_compile", "main.py"},
+		Run:          []string{"python3", "main.py"},
+		AddressSpace: true,
+	})
+}
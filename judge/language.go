@@ -0,0 +1,77 @@
+package judge
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Language is a language plugin: how to build and run submissions in one
+// language. Commands run inside the submission's work directory; "{memory}"
+// in Run is replaced by the memory limit in megabytes, and "{cache}" in Env
+// by the language's cache directory.
+type Language struct {
+	ID      string
+	Name    string
+	Source  string   // file name the code is written to
+	Compile []string // nil for interpreted languages without a check step
+	Run     []string
+	// AddressSpace caps virtual memory as well as resident memory; see
+	// sandbox.Limits.
+	AddressSpace bool
+	// Headroom caps the virtual memory of runtimes that reserve address
+	// space up front at the memory limit plus this many bytes.
+	Headroom int64
+	// Env is added to the environment of both steps.
+	Env []string
+	// Prepare, if set, runs once as the judge's own user before the first
+	// compile, to fill the cache directory. Compiles may read the cache but
+	// not write it, so one submission cannot poison what later ones use.
+	Prepare []string
+}
+
+var languages = make(map[string]Language)
+
+// Register makes a language available to the judge. Each language plugin
+// registers itself from an init function in its own file; registering the
+// same id twice panics.
+func Register(lang Language) {
+	if _, dup := languages[lang.ID]; dup {
+		panic("judge: language " + lang.ID + " registered twice")
+	}
+	languages[lang.ID] = lang
+}
+
+// Lookup returns the language with the given id.
+func Lookup(id string) (Language, bool) {
+	lang, ok := languages[id]
+	return lang, ok
+}
+
+// Languages returns the supported languages sorted by id.
+func Languages() []Language {
+	list := make([]Language, 0, len(languages))
+	for _, lang := range languages {
+		list = append(list, lang)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// env returns Env with the cache directory substituted.
+func (l Language) env(cache string) []string {
+	env := make([]string, len(l.Env))
+	for i, e := range l.Env {
+		env[i] = strings.ReplaceAll(e, "{cache}", cache)
+	}
+	return env
+}
+
+// runArgs returns the run command with the memory limit substituted.
+func (l Language) runArgs(memoryMB int) []string {
+	args := make([]string, len(l.Run))
+	for i, a := range l.Run {
+		args[i] = strings.ReplaceAll(a, "{memory}", strconv.Itoa(memoryMB))
+	}
+	return args
+}
@@ -5,12 +5,22 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
+	"github.com/AbenezerWork/AASTU-CPC/auth"
+	"github.com/AbenezerWork/AASTU-CPC/codeforces"
 	"github.com/AbenezerWork/AASTU-CPC/controllers"
-	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/grading"
+	"github.com/AbenezerWork/AASTU-CPC/importer"
+	"github.com/AbenezerWork/AASTU-CPC/judge"
 	"github.com/AbenezerWork/AASTU-CPC/repository"
 	"github.com/AbenezerWork/AASTU-CPC/routers"
-	"github.com/AbenezerWork/AASTU-CPC/utils"
+	"github.com/AbenezerWork/AASTU-CPC/sandbox"
+	"github.com/AbenezerWork/AASTU-CPC/scoring"
+	"github.com/AbenezerWork/AASTU-CPC/verifier"
+	"github.com/AbenezerWork/AASTU-CPC/worker"
 	"github.com/joho/godotenv"
 
 	"go.mongodb.org/mongo-driver/mongo"
@@ -51,20 +61,187 @@ func main() {
 
 	articleRepo := repository.NewArticleRepository(db)
 	authRepo := repository.NewUserRepository(db)
+	if err := authRepo.EnsureIndexes(context.Background()); err != nil {
+		// fails while two users have verified the same handle; unverify one
+		log.Fatal("user indexes: ", err)
+	}
 	problemRepo := repository.NewProblemRepository(db)
+	if err := problemRepo.EnsureIndexes(context.Background()); err != nil {
+		// hand-entered duplicates predate the index; imports still work, they just can't be enforced
+		log.Println("problem indexes:", err)
+	}
+	problemSetRepo := repository.NewProblemSetRepository(db)
+	if err := problemSetRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 	sessionRepo := repository.NewSessionRepository(db)
+	if err := sessionRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	var sessionKeys *auth.Keyring
+	if config := os.Getenv("SESSION_KEYS"); config != "" {
+		if sessionKeys, err = auth.ParseKeys(config); err != nil {
+			log.Fatal("SESSION_KEYS: ", err)
+		}
+	} else {
+		// sessions won't survive a restart or work across replicas
+		log.Println("SESSION_KEYS not set, signing sessions with a random key")
+		sessionKeys = auth.RandomKeyring()
+	}
+	sessionConfig := auth.DefaultConfig()
+	if ttl, err := time.ParseDuration(os.Getenv("SESSION_TTL")); err == nil {
+		sessionConfig.TTL = ttl
+	}
+	if maxAge, err := time.ParseDuration(os.Getenv("SESSION_MAX_AGE")); err == nil {
+		sessionConfig.MaxAge = maxAge
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("ACCESS_TOKEN_TTL")); err == nil {
+		sessionConfig.AccessTTL = ttl
+	}
+	if name := os.Getenv("SESSION_COOKIE_NAME"); name != "" {
+		sessionConfig.CookieName = name
+	}
+	// plain HTTP during local development
+	sessionConfig.Secure = os.Getenv("SESSION_COOKIE_INSECURE") != "true"
+	sessions := auth.NewManager(sessionKeys, sessionRepo, authRepo, sessionConfig)
 	submissionRepo := repository.NewSubmissionRepository(db)
+	leaderboardRepo := repository.NewLeaderboardRepository(db)
+	ratingRepo := repository.NewRatingHistoryRepository(db)
+	if err := ratingRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	if err := submissionRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	judgeJobRepo := repository.NewJudgeJobRepository(db)
+	if err := judgeJobRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+	testRepo, err := repository.NewTestRepository(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := testRepo.EnsureIndexes(context.Background()); err != nil {
+		log.Fatal(err)
+	}
 
-	articleCtrl := controllers.NewArticleController(articleRepo)
-	authCtrl := controllers.NewAuthController(authRepo, sessionRepo)
-	problemCtrl := controllers.NewProblemController(problemRepo)
-	submissionCtrl := controllers.NewSubmissionController(submissionRepo, problemRepo, authRepo)
+	// CODEFORCES_TIMEOUT is a Go duration such as "10s"; unset means the client default
+	cfTimeout, _ := time.ParseDuration(os.Getenv("CODEFORCES_TIMEOUT"))
+	cfClient := codeforces.NewClient(os.Getenv("CODEFORCES_API_URL"), cfTimeout).
+		WithCredentials(os.Getenv("CODEFORCES_API_KEY"), os.Getenv("CODEFORCES_API_SECRET"))
+	if interval, err := time.ParseDuration(os.Getenv("CODEFORCES_RATE_INTERVAL")); err == nil {
+		cfClient.WithRateLimit(interval, 1)
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("CODEFORCES_CACHE_TTL")); err == nil {
+		cfClient.WithCacheTTL(ttl)
+	}
+
+	verifiers := verifier.NewRegistry()
+	cfVerifier := verifier.NewCodeforces(cfClient)
+	cfVerifier.Policy.AllowedTypes = verifier.ParseParticipantTypes(os.Getenv("CODEFORCES_PARTICIPANT_TYPES"))
+	cfVerifier.Policy.AllowGhosts = os.Getenv("CODEFORCES_ALLOW_GHOSTS") == "true"
+	verifiers.Register("codeforces", cfVerifier)
+	verifiers.Register("atcoder", verifier.NewAtCoder(os.Getenv("ATCODER_URL"), 0))
+	csesVerifier := verifier.NewCSES(os.Getenv("CSES_URL"), 0)
+	verifiers.Register("cses", csesVerifier)
+	verifiers.Register("leetcode", verifier.NewLeetCode(os.Getenv("LEETCODE_GRAPHQL_URL"), 0))
+
+	cfImporter := importer.NewCodeforces(cfClient, problemRepo, problemSetRepo)
+	csesImporter := importer.NewCSES(csesVerifier.BaseURL, problemRepo)
+	scorer := scoring.NewEngine(client, submissionRepo, problemRepo, authRepo, scoring.FormulaFromEnv())
+
+	// JUDGE_WORKDIR holds the per-submission build directories
+	judgeDir := os.Getenv("JUDGE_WORKDIR")
+	if judgeDir == "" {
+		judgeDir = filepath.Join(os.TempDir(), "aastu-judge")
+	}
+	// TESTLIB_DIR is where testlib.h lives if it isn't on the include path
+	grader := grading.New(filepath.Join(judgeDir, "checkers"), os.Getenv("TESTLIB_DIR"))
+	judger := judge.New(testRepo, grader, judgeDir)
+	// SANDBOX_USER is the unprivileged user submissions run as, and
+	// CHECKER_USER another one for checkers and interactors, which read the
+	// expected answers
+	if name := os.Getenv("SANDBOX_USER"); name != "" {
+		isolation, err := sandbox.LookupUser(name)
+		if err != nil {
+			log.Fatal("SANDBOX_USER: ", err)
+		}
+		judger.Isolation = isolation
+	}
+	if name := os.Getenv("CHECKER_USER"); name != "" {
+		isolation, err := sandbox.LookupUser(name)
+		if err != nil {
+			log.Fatal("CHECKER_USER: ", err)
+		}
+		if judger.Isolation != nil && judger.Isolation.UID == isolation.UID {
+			log.Fatal("CHECKER_USER must not be SANDBOX_USER")
+		}
+		grader.Isolation = isolation
+	}
+	judgeWorker := worker.NewJudgeWorker(judgeJobRepo, submissionRepo, problemRepo, judger, scorer)
+	if n, err := strconv.Atoi(os.Getenv("JUDGE_WORKERS")); err == nil {
+		// judging is left to judge-worker processes unless this is set
+		judgeWorker.Workers = n
+	}
+
+	// one-off maintenance commands, e.g. `go run . recompute-scores`
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "recompute-scores":
+			recomputeScores(scorer)
+		case "import-problemset":
+			importProblemset(cfImporter, os.Args[2:])
+		case "import-contest":
+			importContest(cfImporter, os.Args[2:])
+		case "import-cses":
+			importCSES(csesImporter)
+		case "judge-worker":
+			runJudgeWorker(judgeWorker, os.Args[2:])
+		default:
+			log.Fatalf("unknown command %q", os.Args[1])
+		}
+		return
+	}
+
+	verificationWorker := worker.NewVerificationWorker(submissionRepo, problemRepo, authRepo, verifiers, scorer)
+	if n, err := strconv.Atoi(os.Getenv("VERIFY_WORKERS")); err == nil && n > 0 {
+		verificationWorker.Workers = n
+	}
+	go verificationWorker.Run(context.Background())
 
-	//checking the cf request module
-	err, bl := utils.GetAndCheckAdmission(models.Problem{ContestID: "1859", Index: "B"}, "310872613", "FunkyLlama")
+	if judgeWorker.Workers > 0 {
+		requireIsolation(judgeWorker)
+		go judgeWorker.Run(context.Background())
+	}
 
-	fmt.Println(err, bl)
+	solveSync := worker.NewSolveSync(cfClient, cfVerifier, submissionRepo, problemRepo, authRepo, scorer)
+	if interval, err := time.ParseDuration(os.Getenv("CODEFORCES_SYNC_INTERVAL")); err == nil {
+		solveSync.Interval = interval
+	}
+	if os.Getenv("CODEFORCES_SYNC_INTERVAL") != "off" {
+		go solveSync.Run(context.Background())
+	}
+
+	ratingSync := worker.NewRatingSync(cfClient, authRepo, ratingRepo)
+	if interval, err := time.ParseDuration(os.Getenv("CODEFORCES_RATING_SYNC_INTERVAL")); err == nil {
+		ratingSync.Interval = interval
+	}
+	if os.Getenv("CODEFORCES_RATING_SYNC_INTERVAL") != "off" {
+		go ratingSync.Run(context.Background())
+	}
+
+	articleCtrl := controllers.NewArticleController(articleRepo)
+	authCtrl := controllers.NewAuthController(authRepo, sessionRepo, sessions)
+	problemCtrl := controllers.NewProblemController(problemRepo, testRepo)
+	problemSetCtrl := controllers.NewProblemSetController(problemSetRepo, problemRepo)
+	submissionCtrl := controllers.NewSubmissionController(submissionRepo, judgeJobRepo, problemRepo, authRepo, verifiers)
+	codeforcesCtrl := controllers.NewCodeforcesController(cfClient, cfVerifier, authRepo)
+	leaderboardCtrl := controllers.NewLeaderboardController(leaderboardRepo)
+	importCtrl := controllers.NewImportController(cfImporter, csesImporter)
+	ratingCtrl := controllers.NewRatingController(ratingRepo)
+	testCtrl := controllers.NewTestController(testRepo, problemRepo)
+	accountCtrl := controllers.NewAccountController(verifiers, authRepo)
 
-	r := routers.SetupRouter(articleCtrl, problemCtrl, authCtrl, sessionRepo, submissionCtrl)
+	r := routers.SetupRouter(articleCtrl, problemCtrl, authCtrl, sessions, submissionCtrl, codeforcesCtrl, leaderboardCtrl, importCtrl, problemSetCtrl, ratingCtrl, testCtrl, accountCtrl)
 	r.Run(":8080")
 }
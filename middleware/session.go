@@ -1,72 +1,48 @@
 package middleware
 
 import (
-	"context"
-	"fmt"
 	"net/http"
 
-	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/auth"
 	"github.com/gin-gonic/gin"
 )
 
-func AdminAuthRequired(sessionRepo *repository.SessionRepository) gin.HandlerFunc {
+// AuthRequired rejects requests without a live session, i.e. one that is
+// signed, unexpired and not revoked. Browsers authenticate with the session
+// cookie, API clients with an "Authorization: Bearer" access token from
+// POST /token. It stores the user's id under "userID" and the session
+// under "session".
+func AuthRequired(sessions *auth.Manager) gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		cookies := c.Request.Cookies()
-
-		var session *http.Cookie
-
-		for _, cookie := range cookies {
-			fmt.Println("COOKIE", cookie.Value, cookie.Name)
-			if cookie.Name == "Set-Cookie" {
-				session = cookie
-			}
-		}
-		fmt.Println("HELLO")
-		if session == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
-			return
-		}
-
-		sessionModel, err := sessionRepo.GetBySessionID(context.Background(), session.Value)
-		if !sessionModel.IsAdmin || err != nil {
+		session, err := sessions.AuthenticateRequest(c.Writer, c.Request)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
+		c.Set("userID", session.UserID)
+		c.Set("session", *session)
 		c.Next()
 	}
 }
 
-func AuthRequired(sessionRepo *repository.SessionRepository) gin.HandlerFunc {
+// RequirePermission is AuthRequired for users whose role grants all of
+// perms; others get 403.
+func RequirePermission(sessions *auth.Manager, perms ...auth.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		cookies := c.Request.Cookies()
-
-		var session *http.Cookie
-
-		for _, cookie := range cookies {
-			fmt.Println("COOKIE", cookie.Value, cookie.Name)
-			if cookie.Name == "Set-Cookie" {
-				session = cookie
-			}
-		}
-		fmt.Println("HELLO")
-		if session == nil {
+		session, err := sessions.AuthenticateRequest(c.Writer, c.Request)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
-
-		sessionModel, err := sessionRepo.GetBySessionID(context.Background(), session.Value)
-		fmt.Println(sessionModel.SessionID, session.Value)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		if !auth.Can(session.Role, perms...) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
 			c.Abort()
 			return
 		}
-		c.Set("userID", sessionModel.UserID)
+		c.Set("userID", session.UserID)
+		c.Set("session", *session)
 		c.Next()
 	}
 }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// States of a JudgeJob.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// JudgeJob is a native submission waiting for, or being graded by, a judge
+// worker. A running job is leased to one worker; if the worker stops
+// renewing the lease another one picks the job up.
+type JudgeJob struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubmissionID  primitive.ObjectID `bson:"submission_id" json:"submission_id"`
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	WorkerID      string             `bson:"worker_id,omitempty" json:"worker_id,omitempty"`
+	LeaseUntil    time.Time          `bson:"lease_until,omitempty" json:"lease_until,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"next_attempt_at"`
+	Error         string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LeaderboardFilter selects the solves a leaderboard is computed from.
+type LeaderboardFilter struct {
+	From     time.Time
+	To       time.Time
+	Division string
+	Tag      string
+	Page     int
+	Limit    int
+}
+
+type LeaderboardEntry struct {
+	Rank               int                `bson:"-" json:"rank"`
+	UserID             primitive.ObjectID `bson:"_id" json:"user_id"`
+	UserName           string             `bson:"user_name" json:"user_name"`
+	CodeforcesUsername string             `bson:"codeforces_username" json:"codeforces_username"`
+	Division           string             `bson:"division" json:"division"`
+	Score              int64              `bson:"score" json:"score"`
+	Solved             int                `bson:"solved" json:"solved"`
+	LastAcceptedAt     time.Time          `bson:"last_accepted_at" json:"last_accepted_at"`
+}
+
+type Leaderboard struct {
+	Total   int64              `json:"total"`
+	Page    int                `json:"page"`
+	Limit   int                `json:"limit"`
+	Entries []LeaderboardEntry `json:"entries"`
+}
@@ -1,6 +1,10 @@
 package models
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Mentor struct {
 	Name  string `bson:"name" json:"name"`
@@ -13,28 +17,122 @@ type Credentials struct {
 	Password string `json:"password"`
 }
 
+// Grant types of POST /token.
+const (
+	GrantPassword     = "password"
+	GrantRefreshToken = "refresh_token"
+)
+
+// TokenRequest asks POST /token for bearer tokens, either with a username
+// and password or with a refresh token.
+type TokenRequest struct {
+	GrantType    string `json:"grant_type"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// Ways of proving ownership of a judge handle.
+const (
+	// ChallengeCompileError asks the user to submit code that fails to
+	// compile to a given Codeforces problem before the challenge expires.
+	ChallengeCompileError = "compile-error"
+	// ChallengeFirstName asks the user to set the first name on their
+	// Codeforces profile to a token.
+	ChallengeFirstName = "first-name"
+	// ChallengeAffiliation asks the user to set the affiliation on their
+	// AtCoder profile to a token.
+	ChallengeAffiliation = "affiliation"
+	// ChallengeName asks the user to set the name of their CSES account
+	// to a token.
+	ChallengeName = "name"
+	// ChallengeAboutMe asks the user to put a token in the summary of
+	// their LeetCode profile.
+	ChallengeAboutMe = "about-me"
+)
+
+// HandleChallenge is an outstanding request to prove ownership of a handle.
+type HandleChallenge struct {
+	Method    string    `bson:"method" json:"method"`
+	Handle    string    `bson:"handle" json:"handle"`
+	Token     string    `bson:"token,omitempty" json:"token,omitempty"`
+	ContestID string    `bson:"contest_id,omitempty" json:"contest_id,omitempty"`
+	Index     string    `bson:"index,omitempty" json:"index,omitempty"`
+	IssuedAt  time.Time `bson:"issued_at" json:"issued_at"`
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+}
+
 type User struct {
-	ID                 primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	Score              int64              `bson:"score" json:"score"`
-	Role               string             `bson:"role" json:"role"`
-	Mentor             Mentor             `bson:"mentor" json:"mentor"`
-	UserName           string             `bson:"user_name" json:"user_name" validate:"required"`
-	CodeforcesUsername string             `bson:"codeforces_username" json:"codeforces_username" validate:"required"`
-	PasswordHash       string             `bson:"password" json:"password" validate:"required"`
+	ID                         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Score                      int64              `bson:"score" json:"score"`
+	Role                       string             `bson:"role" json:"role"`
+	Mentor                     Mentor             `bson:"mentor" json:"mentor"`
+	Division                   string             `bson:"division" json:"division"`
+	UserName                   string             `bson:"user_name" json:"user_name" validate:"required"`
+	CodeforcesUsername         string             `bson:"codeforces_username" json:"codeforces_username" validate:"required"`
+	CodeforcesVerified         bool               `bson:"codeforces_verified" json:"codeforces_verified"`
+	CodeforcesChallenge        *HandleChallenge   `bson:"codeforces_challenge,omitempty" json:"-"`
+	CodeforcesLastSubmissionID int64              `bson:"codeforces_last_submission_id" json:"-"` // newest submission seen by the solve sync
+	AtCoderUsername            string             `bson:"atcoder_username" json:"atcoder_username"`
+	AtCoderVerified            bool               `bson:"atcoder_verified" json:"atcoder_verified"`
+	AtCoderChallenge           *HandleChallenge   `bson:"atcoder_challenge,omitempty" json:"-"`
+	CSESUserID                 string             `bson:"cses_user_id" json:"cses_user_id"`
+	CSESVerified               bool               `bson:"cses_verified" json:"cses_verified"`
+	CSESChallenge              *HandleChallenge   `bson:"cses_challenge,omitempty" json:"-"`
+	LeetCodeUsername           string             `bson:"leetcode_username" json:"leetcode_username"`
+	LeetCodeVerified           bool               `bson:"leetcode_verified" json:"leetcode_verified"`
+	LeetCodeChallenge          *HandleChallenge   `bson:"leetcode_challenge,omitempty" json:"-"`
+	PasswordHash               string             `bson:"password" json:"password" validate:"required"`
+}
+
+// Account returns the user's handle on judge, whether they proved they own
+// it and the challenge they were set to prove it, for the judges linked
+// through /me/accounts.
+func (u *User) Account(judge string) (handle string, verified bool, challenge *HandleChallenge) {
+	switch judge {
+	case "atcoder":
+		return u.AtCoderUsername, u.AtCoderVerified, u.AtCoderChallenge
+	case "cses":
+		return u.CSESUserID, u.CSESVerified, u.CSESChallenge
+	case "leetcode":
+		return u.LeetCodeUsername, u.LeetCodeVerified, u.LeetCodeChallenge
+	}
+	return "", false, nil
 }
 
 type Problem struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Author           string             `bson:"author" json:"author"`
+	OwnerID          primitive.ObjectID `bson:"owner_id,omitempty" json:"owner_id,omitempty"` // user who created it; unset for imported problems
 	Title            string             `bson:"title" json:"title"`
 	ProblemStatement string             `bson:"problem_statement" json:"problem_statement"`
 	Source           string             `bson:"source" json:"source"`
 	Difficulty       int                `bson:"difficulty" json:"difficulty"`
 	ContestID        string             `bson:"contest_id" json:"contest_id"`
-	Index            string             `json:"index"`
+	Index            string             `bson:"index" json:"index"`
+	Slug             string             `bson:"slug,omitempty" json:"slug,omitempty"`
 	Tags             []string           `bson:"tags" json:"tags"`
+	// Limits applied by the built-in judge to native problems; zero means
+	// the judge default.
+	TimeLimit   int `bson:"time_limit,omitempty" json:"time_limit,omitempty"`     // milliseconds
+	MemoryLimit int `bson:"memory_limit,omitempty" json:"memory_limit,omitempty"` // megabytes
+	OutputLimit int `bson:"output_limit,omitempty" json:"output_limit,omitempty"` // megabytes
+	// Checker grades answers when more than one is correct; without one
+	// the output must match the expected answer. Interactor talks to the
+	// solution on interactive problems.
+	Checker    *Program `bson:"checker,omitempty" json:"checker,omitempty"`
+	Interactor *Program `bson:"interactor,omitempty" json:"interactor,omitempty"`
+}
+
+// Program is the source of a checker or interactor: C++17 with testlib.h
+// available, reporting its verdict through testlib exit codes.
+type Program struct {
+	Source string `bson:"source" json:"source"`
 }
 
+// JudgeNative is the Source of problems graded by the built-in judge.
+const JudgeNative = "native"
+
 type Article struct {
 	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Author   string             `bson:"author" json:"author"`
@@ -42,12 +140,63 @@ type Article struct {
 	Blog     string             `bson:"blog" json:"blog"`
 	Tags     []string           `bson:"tags" json:"tags"`
 	Problems []Problem          `bson:"problems" json:"problems"`
-	Division string             `bson:"division" json:"division"`
+	// ProblemSetIDs references problem sets assigned by the article.
+	ProblemSetIDs []primitive.ObjectID `bson:"problem_set_ids" json:"problem_set_ids"`
+	Division      string               `bson:"division" json:"division"`
+	OwnerID       primitive.ObjectID   `bson:"owner_id,omitempty" json:"owner_id,omitempty"`
+	// Draft articles are hidden from readers until someone allowed to
+	// publish them clears the flag.
+	Draft bool `bson:"draft,omitempty" json:"draft,omitempty"`
 }
 
+// Verdicts of the built-in judge, per test and for a whole submission.
+const (
+	VerdictAccepted            = "AC"
+	VerdictWrongAnswer         = "WA"
+	VerdictTimeLimitExceeded   = "TLE"
+	VerdictMemoryLimitExceeded = "MLE"
+	VerdictRuntimeError        = "RE"
+	VerdictCompilationError    = "CE"
+)
+
+// TestResult is the outcome of running a submission on one test.
+type TestResult struct {
+	TestID  primitive.ObjectID `bson:"test_id" json:"test_id"`
+	GroupID primitive.ObjectID `bson:"group_id" json:"group_id"`
+	Verdict string             `bson:"verdict" json:"verdict"`
+	Time    int64              `bson:"time" json:"time"`     // milliseconds of CPU time
+	Memory  int64              `bson:"memory" json:"memory"` // kilobytes
+	Message string             `bson:"message,omitempty" json:"message,omitempty"`
+}
+
+// Verification states of a Submission.
+const (
+	SubmissionPending   = "pending"
+	SubmissionVerifying = "verifying"
+	SubmissionAccepted  = "accepted"
+	SubmissionRejected  = "rejected"
+	SubmissionError     = "error"
+)
+
 type Submission struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	UserID     string             `bson:"user_id" json:"user_id"`
-	ProblemID  string             `bson:"problem_id" json:"problem_id"`
-	Submission string             `bson:"submission" json:"submission"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID        string             `bson:"user_id" json:"user_id"`
+	ProblemID     string             `bson:"problem_id" json:"problem_id"`
+	Judge         string             `bson:"judge" json:"judge"`
+	Submission    string             `bson:"submission" json:"submission"`
+	Language      string             `bson:"language,omitempty" json:"language,omitempty"` // native problems only
+	Code          string             `bson:"code,omitempty" json:"code,omitempty"`
+	Verdict       string             `bson:"verdict,omitempty" json:"verdict,omitempty"`
+	CompileOutput string             `bson:"compile_output,omitempty" json:"compile_output,omitempty"`
+	Tests         []TestResult       `bson:"tests,omitempty" json:"tests,omitempty"`
+	TestsTotal    int                `bson:"tests_total,omitempty" json:"tests_total,omitempty"` // len(Tests) of TestsTotal are done
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	Error         string             `bson:"error,omitempty" json:"error,omitempty"`
+	Difficulty    int                `bson:"difficulty" json:"difficulty"`
+	Points        int64              `bson:"points" json:"points"`
+	VerifiedAt    time.Time          `bson:"verified_at,omitempty" json:"verified_at,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at" json:"-"`
+	CreatedAt     time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time          `bson:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProblemSet is an ordered group of problems, such as a whole contest
+// assigned for weekly training.
+type ProblemSet struct {
+	ID         primitive.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	Title      string               `bson:"title" json:"title"`
+	Source     string               `bson:"source" json:"source"`
+	ContestID  string               `bson:"contest_id" json:"contest_id"`
+	Division   string               `bson:"division" json:"division"`
+	Tags       []string             `bson:"tags" json:"tags"`
+	ProblemIDs []primitive.ObjectID `bson:"problem_ids" json:"problem_ids"`
+	CreatedAt  time.Time            `bson:"created_at" json:"created_at"`
+	// Problems holds the problems of ProblemIDs in order when a set is
+	// returned with its problems; it is not stored.
+	Problems []Problem `bson:"-" json:"problems,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RatingChange is one rated Codeforces contest of a member.
+type RatingChange struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID      primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Handle      string             `bson:"handle" json:"handle"`
+	ContestID   int                `bson:"contest_id" json:"contest_id"`
+	ContestName string             `bson:"contest_name" json:"contest_name"`
+	Rank        int                `bson:"rank" json:"rank"`
+	OldRating   int                `bson:"old_rating" json:"old_rating"`
+	NewRating   int                `bson:"new_rating" json:"new_rating"`
+	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -6,10 +6,24 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Session is a login on one device; a user can have any number of them.
+// SessionID is a hash of the secret in the token the client holds, never
+// the token itself.
 type Session struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"`
-	SessionID string             `bson:"session_id"`
-	UserID    primitive.ObjectID `bson:"user_id"`
-	IsAdmin   bool               `bson:"isadmin"`
-	Issued    time.Time          `bson:"timestamp"`
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SessionID string             `bson:"session_id" json:"-"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"-"`
+	// Role is the user's role, kept current when it changes.
+	Role       string    `bson:"role" json:"-"`
+	Issued     time.Time `bson:"timestamp" json:"issued"`
+	LastSeenAt time.Time `bson:"last_seen_at" json:"last_seen_at"`
+	// ExpiresAt slides forward while the session is used, up to a maximum
+	// age counted from Issued. Mongo deletes the session once it passes.
+	ExpiresAt time.Time `bson:"expires_at" json:"expires_at"`
+	RevokedAt time.Time `bson:"revoked_at,omitempty" json:"-"`
+
+	// Device is a readable summary of UserAgent, e.g. "Firefox on Linux".
+	Device    string `bson:"device,omitempty" json:"device,omitempty"`
+	UserAgent string `bson:"user_agent,omitempty" json:"user_agent,omitempty"`
+	IP        string `bson:"ip,omitempty" json:"ip,omitempty"`
 }
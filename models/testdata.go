@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// TestCase is one input/answer pair of a native problem. The files live in
+// the "testdata" GridFS bucket.
+type TestCase struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	Name         string             `bson:"name" json:"name"`
+	InputFileID  primitive.ObjectID `bson:"input_file_id" json:"input_file_id"`
+	OutputFileID primitive.ObjectID `bson:"output_file_id" json:"output_file_id"`
+	InputSHA256  string             `bson:"input_sha256" json:"input_sha256"`
+	OutputSHA256 string             `bson:"output_sha256" json:"output_sha256"`
+	InputSize    int64              `bson:"input_size" json:"input_size"`
+	OutputSize   int64              `bson:"output_size" json:"output_size"`
+	Sample       bool               `bson:"sample" json:"sample"`
+}
+
+// TestGroup is a subtask; its points are awarded when every test passes.
+type TestGroup struct {
+	ID     primitive.ObjectID `bson:"_id" json:"id"`
+	Name   string             `bson:"name" json:"name"`
+	Points int                `bson:"points" json:"points"`
+	Tests  []TestCase         `bson:"tests" json:"tests"`
+}
+
+// ProblemTests holds the ordered test groups of a problem. Version counts
+// saves, so that concurrent edits do not overwrite each other.
+type ProblemTests struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	ProblemID primitive.ObjectID `bson:"problem_id" json:"problem_id"`
+	Groups    []TestGroup        `bson:"groups" json:"groups"`
+	Version   int64              `bson:"version" json:"version"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Group returns the group with the given id, or nil.
+func (t *ProblemTests) Group(id primitive.ObjectID) *TestGroup {
+	for i := range t.Groups {
+		if t.Groups[i].ID == id {
+			return &t.Groups[i]
+		}
+	}
+	return nil
+}
@@ -42,14 +42,15 @@ func (r *ArticleRepository) GetByID(ctx context.Context, id primitive.ObjectID)
 	return &article, nil
 }
 
-// GetAll retrieves all articles
+// GetAll retrieves all published articles
 func (r *ArticleRepository) GetAll(ctx context.Context, page int, limit int, search string, sort string) ([]models.Article, error) {
 	skip := (page - 1) * limit
 
-	// Build the filter for search
-	filter := bson.M{}
+	// Build the filter for search; drafts are never listed
+	filter := bson.M{"draft": bson.M{"$ne": true}}
 	if search != "" {
 		filter = bson.M{
+			"draft": bson.M{"$ne": true},
 			"$or": []bson.M{
 				{"title": bson.M{"$regex": search, "$options": "i"}},
 				{"content": bson.M{"$regex": search, "$options": "i"}},
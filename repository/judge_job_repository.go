@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JudgeJobRepository is the queue of native submissions to grade.
+type JudgeJobRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewJudgeJobRepository(db *mongo.Database) *JudgeJobRepository {
+	return &JudgeJobRepository{
+		Collection: db.Collection("judge_jobs"),
+	}
+}
+
+// EnsureIndexes creates the indexes claiming relies on and keeps one job
+// per submission.
+func (r *JudgeJobRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "lease_until", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "submission_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	return err
+}
+
+// Enqueue queues a submission for judging. A submission that already has a
+// job keeps it, so enqueueing twice is harmless.
+func (r *JudgeJobRepository) Enqueue(ctx context.Context, submissionID primitive.ObjectID) error {
+	now := time.Now()
+	_, err := r.Collection.UpdateOne(ctx,
+		bson.M{"submission_id": submissionID},
+		bson.M{"$setOnInsert": bson.M{
+			"submission_id":   submissionID,
+			"status":          models.JobQueued,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"created_at":      now,
+			"updated_at":      now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// ErrAttemptsExhausted is returned by Claim, together with the job, when
+// the job it claimed had already been tried maxAttempts times. The job is
+// failed; its submission is left to the caller.
+var ErrAttemptsExhausted = errors.New("job has no attempts left")
+
+// Claim leases the oldest due job to workerID until now+lease. Jobs whose
+// lease ran out, e.g. because their worker died, are claimed again unless
+// they are out of attempts, in which case they are failed. It returns
+// mongo.ErrNoDocuments when there is nothing to do.
+func (r *JudgeJobRepository) Claim(ctx context.Context, workerID string, lease time.Duration, maxAttempts int) (*models.JudgeJob, error) {
+	now := time.Now()
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": models.JobQueued, "next_attempt_at": bson.M{"$lte": now}},
+		bson.M{"status": models.JobRunning, "lease_until": bson.M{"$lt": now}},
+	}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.JobRunning,
+			"worker_id":   workerID,
+			"lease_until": now.Add(lease),
+			"updated_at":  now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.JudgeJob
+	if err := r.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job); err != nil {
+		return nil, err
+	}
+	if job.Attempts > maxAttempts {
+		// every attempt ended with its worker gone, e.g. because the
+		// submission crashes the judge
+		msg := fmt.Sprintf("gave up after %d attempts", maxAttempts)
+		if err := r.Finish(ctx, job.ID, workerID, models.JobFailed, msg); err != nil {
+			return nil, err
+		}
+		job.Status, job.Error = models.JobFailed, msg
+		return &job, ErrAttemptsExhausted
+	}
+	return &job, nil
+}
+
+// Extend renews the lease of a running job. It returns mongo.ErrNoDocuments
+// if workerID no longer holds the job.
+func (r *JudgeJobRepository) Extend(ctx context.Context, id primitive.ObjectID, workerID string, lease time.Duration) error {
+	now := time.Now()
+	result, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.JobRunning, "worker_id": workerID},
+		bson.M{"$set": bson.M{"lease_until": now.Add(lease), "updated_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// Finish records the final state of a job, done or failed. It returns
+// mongo.ErrNoDocuments if workerID no longer holds the job.
+func (r *JudgeJobRepository) Finish(ctx context.Context, id primitive.ObjectID, workerID string, status string, errMsg string) error {
+	return r.release(ctx, id, workerID, bson.M{"$set": bson.M{
+		"status":     status,
+		"error":      errMsg,
+		"updated_at": time.Now(),
+	}})
+}
+
+// Retry puts a job back in the queue to be tried again at next. It returns
+// mongo.ErrNoDocuments if workerID no longer holds the job.
+func (r *JudgeJobRepository) Retry(ctx context.Context, id primitive.ObjectID, workerID string, next time.Time, errMsg string) error {
+	return r.release(ctx, id, workerID, bson.M{
+		"$set": bson.M{
+			"status":          models.JobQueued,
+			"error":           errMsg,
+			"next_attempt_at": next,
+			"updated_at":      time.Now(),
+		},
+		"$unset": bson.M{"worker_id": "", "lease_until": ""},
+	})
+}
+
+// release applies update to a running job held by workerID.
+func (r *JudgeJobRepository) release(ctx context.Context, id primitive.ObjectID, workerID string, update bson.M) error {
+	result, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "status": models.JobRunning, "worker_id": workerID},
+		update,
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
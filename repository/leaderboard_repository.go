@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// LeaderboardRepository ranks users by aggregating accepted submissions.
+type LeaderboardRepository struct {
+	Submissions *mongo.Collection
+}
+
+func NewLeaderboardRepository(db *mongo.Database) *LeaderboardRepository {
+	return &LeaderboardRepository{
+		Submissions: db.Collection("submission"),
+	}
+}
+
+// toObjectID converts a hex string field to an ObjectID, yielding null for
+// malformed ids so a single bad document cannot fail the whole pipeline.
+func toObjectID(field string) bson.M {
+	return bson.M{"$convert": bson.M{"input": field, "to": "objectId", "onError": nil, "onNull": nil}}
+}
+
+// Get returns one page of users ranked by points earned in the filter's time
+// window. Ties go to whoever reached the score first.
+func (r *LeaderboardRepository) Get(ctx context.Context, filter models.LeaderboardFilter) (*models.Leaderboard, error) {
+	match := bson.M{"status": models.SubmissionAccepted}
+	window := bson.M{}
+	if !filter.From.IsZero() {
+		window["$gte"] = filter.From
+	}
+	if !filter.To.IsZero() {
+		window["$lt"] = filter.To
+	}
+	if len(window) > 0 {
+		match["verified_at"] = window
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+
+	if filter.Tag != "" {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$addFields", Value: bson.M{"problem_oid": toObjectID("$problem_id")}}},
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from":         "problems",
+				"localField":   "problem_oid",
+				"foreignField": "_id",
+				"as":           "problem",
+			}}},
+			bson.D{{Key: "$match", Value: bson.M{"problem.tags": filter.Tag}}},
+		)
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":              "$user_id",
+			"score":            bson.M{"$sum": "$points"},
+			"solved":           bson.M{"$sum": 1},
+			"last_accepted_at": bson.M{"$max": "$verified_at"},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{"user_oid": toObjectID("$_id")}}},
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from":         "users",
+			"localField":   "user_oid",
+			"foreignField": "_id",
+			"as":           "user",
+		}}},
+		bson.D{{Key: "$unwind", Value: "$user"}},
+	)
+
+	if filter.Division != "" {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"user.division": filter.Division}}})
+	}
+
+	skip := (filter.Page - 1) * filter.Limit
+	pipeline = append(pipeline,
+		bson.D{{Key: "$project", Value: bson.M{
+			"_id":                 "$user_oid",
+			"user_name":           "$user.user_name",
+			"codeforces_username": "$user.codeforces_username",
+			"division":            "$user.division",
+			"score":               1,
+			"solved":              1,
+			"last_accepted_at":    1,
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{
+			{Key: "score", Value: -1},
+			{Key: "last_accepted_at", Value: 1},
+			{Key: "_id", Value: 1},
+		}}},
+		bson.D{{Key: "$facet", Value: bson.M{
+			"total":   bson.A{bson.M{"$count": "n"}},
+			"entries": bson.A{bson.M{"$skip": skip}, bson.M{"$limit": filter.Limit}},
+		}}},
+	)
+
+	cursor, err := r.Submissions.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result []struct {
+		Total   []struct{ N int64 }       `bson:"total"`
+		Entries []models.LeaderboardEntry `bson:"entries"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, err
+	}
+
+	leaderboard := &models.Leaderboard{
+		Page:    filter.Page,
+		Limit:   filter.Limit,
+		Entries: []models.LeaderboardEntry{},
+	}
+	if len(result) > 0 {
+		if len(result[0].Total) > 0 {
+			leaderboard.Total = result[0].Total[0].N
+		}
+		if result[0].Entries != nil {
+			leaderboard.Entries = result[0].Entries
+		}
+	}
+	for i := range leaderboard.Entries {
+		leaderboard.Entries[i].Rank = skip + i + 1
+	}
+	return leaderboard, nil
+}
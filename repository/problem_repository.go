@@ -21,6 +21,48 @@ func NewProblemRepository(db *mongo.Database) *ProblemRepository {
 	}
 }
 
+// EnsureIndexes makes (source, contest_id, index) unique for problems that
+// come from an external judge, which is what imports upsert on.
+func (r *ProblemRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "source", Value: 1}, {Key: "contest_id", Value: 1}, {Key: "index", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"contest_id": bson.M{"$gt": ""}}),
+	})
+	return err
+}
+
+// UpsertMany inserts problems or refreshes the judge metadata of the ones
+// already stored, matching on (source, contest_id, index). Fields edited on
+// our side, such as the statement and author, are only set on insert.
+func (r *ProblemRepository) UpsertMany(ctx context.Context, problems []models.Problem) (inserted int64, updated int64, err error) {
+	if len(problems) == 0 {
+		return 0, 0, nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(problems))
+	for _, p := range problems {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"source": p.Source, "contest_id": p.ContestID, "index": p.Index}).
+			SetUpdate(bson.M{
+				"$set": bson.M{
+					"title":      p.Title,
+					"difficulty": p.Difficulty,
+					"tags":       p.Tags,
+				},
+				"$setOnInsert": bson.M{
+					"author":            p.Author,
+					"problem_statement": p.ProblemStatement,
+				},
+			}).
+			SetUpsert(true))
+	}
+	result, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, 0, err
+	}
+	return result.UpsertedCount, result.ModifiedCount, nil
+}
+
 // Create creates a new problem
 func (r *ProblemRepository) Create(ctx context.Context, problem *models.Problem) (*models.Problem, error) {
 	result, err := r.collection.InsertOne(ctx, problem)
@@ -47,6 +89,58 @@ func (r *ProblemRepository) GetByID(ctx context.Context, id string) (*models.Pro
 	return &problem, nil
 }
 
+// GetBySourceKey retrieves the problem of an external judge by contest and index
+func (r *ProblemRepository) GetBySourceKey(ctx context.Context, source string, contestID string, index string) (*models.Problem, error) {
+	var problem models.Problem
+	err := r.collection.FindOne(ctx, bson.M{"source": source, "contest_id": contestID, "index": index}).Decode(&problem)
+	if err != nil {
+		return nil, err
+	}
+	return &problem, nil
+}
+
+// GetByContest retrieves the problems of a contest on an external judge
+func (r *ProblemRepository) GetByContest(ctx context.Context, source string, contestID string) ([]models.Problem, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"source": source, "contest_id": contestID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var problems []models.Problem
+	if err := cursor.All(ctx, &problems); err != nil {
+		return nil, err
+	}
+	return problems, nil
+}
+
+// GetByIDs retrieves problems by their IDs in the order of ids, skipping
+// problems that no longer exist
+func (r *ProblemRepository) GetByIDs(ctx context.Context, ids []primitive.ObjectID) ([]models.Problem, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var found []models.Problem
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, err
+	}
+	byID := make(map[primitive.ObjectID]models.Problem, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+
+	problems := make([]models.Problem, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			problems = append(problems, p)
+		}
+	}
+	return problems, nil
+}
+
 // Update updates an existing problem
 func (r *ProblemRepository) Update(ctx context.Context, problem *models.Problem) error {
 	_, err := r.collection.ReplaceOne(
@@ -57,6 +151,16 @@ func (r *ProblemRepository) Update(ctx context.Context, problem *models.Problem)
 	return err
 }
 
+// TouchSolves bumps a counter on the problem whenever a solve of it is
+// awarded. Two transactions awarding solves of the same problem then write
+// the same document and conflict, so one is retried once the other has
+// committed and its first-solve check sees the other solve. The counter
+// itself is never read.
+func (r *ProblemRepository) TouchSolves(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.UpdateByID(ctx, id, bson.M{"$inc": bson.M{"solve_writes": 1}})
+	return err
+}
+
 // Delete removes a problem by its ID
 func (r *ProblemRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
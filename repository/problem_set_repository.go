@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProblemSetRepository struct {
+	collection *mongo.Collection
+}
+
+func NewProblemSetRepository(db *mongo.Database) *ProblemSetRepository {
+	return &ProblemSetRepository{
+		collection: db.Collection("problem_sets"),
+	}
+}
+
+// EnsureIndexes makes imported sets unique per (source, contest_id).
+func (r *ProblemSetRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "source", Value: 1}, {Key: "contest_id", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"contest_id": bson.M{"$gt": ""}}),
+	})
+	return err
+}
+
+// Create creates a new problem set
+func (r *ProblemSetRepository) Create(ctx context.Context, set *models.ProblemSet) (*models.ProblemSet, error) {
+	result, err := r.collection.InsertOne(ctx, set)
+	if err != nil {
+		return nil, err
+	}
+
+	set.ID = result.InsertedID.(primitive.ObjectID)
+	return set, nil
+}
+
+// UpsertByContest creates the set imported from a contest or refreshes its
+// title and problems if it was imported before.
+func (r *ProblemSetRepository) UpsertByContest(ctx context.Context, set *models.ProblemSet) (*models.ProblemSet, error) {
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	var stored models.ProblemSet
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"source": set.Source, "contest_id": set.ContestID},
+		bson.M{
+			"$set": bson.M{
+				"title":       set.Title,
+				"problem_ids": set.ProblemIDs,
+			},
+			"$setOnInsert": bson.M{
+				"division":   set.Division,
+				"tags":       set.Tags,
+				"created_at": set.CreatedAt,
+			},
+		},
+		opts,
+	).Decode(&stored)
+	if err != nil {
+		return nil, err
+	}
+	return &stored, nil
+}
+
+// GetByID retrieves a problem set by its ID
+func (r *ProblemSetRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.ProblemSet, error) {
+	var set models.ProblemSet
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&set)
+	if err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// GetAll retrieves problem sets, newest first, optionally of one division
+func (r *ProblemSetRepository) GetAll(ctx context.Context, page int, limit int, division string) ([]models.ProblemSet, error) {
+	filter := bson.M{}
+	if division != "" {
+		filter["division"] = division
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sets []models.ProblemSet
+	if err := cursor.All(ctx, &sets); err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+// Update updates an existing problem set
+func (r *ProblemSetRepository) Update(ctx context.Context, set *models.ProblemSet) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": set.ID}, set)
+	return err
+}
+
+// Delete removes a problem set by its ID
+func (r *ProblemSetRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type RatingHistoryRepository struct {
+	Collection *mongo.Collection
+}
+
+func NewRatingHistoryRepository(db *mongo.Database) *RatingHistoryRepository {
+	return &RatingHistoryRepository{
+		Collection: db.Collection("rating_history"),
+	}
+}
+
+// EnsureIndexes keeps one entry per user and contest.
+func (r *RatingHistoryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "contest_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// UpsertMany stores changes, replacing entries already mirrored for the same
+// user and contest. It returns how many entries were new.
+func (r *RatingHistoryRepository) UpsertMany(ctx context.Context, changes []models.RatingChange) (int64, error) {
+	if len(changes) == 0 {
+		return 0, nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(changes))
+	for _, change := range changes {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"user_id": change.UserID, "contest_id": change.ContestID}).
+			SetReplacement(change).
+			SetUpsert(true))
+	}
+	result, err := r.Collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+	return result.UpsertedCount, nil
+}
+
+// GetByUserID returns the user's rating history, oldest first.
+func (r *RatingHistoryRepository) GetByUserID(ctx context.Context, userID primitive.ObjectID) ([]models.RatingChange, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "updated_at", Value: 1}})
+	cursor, err := r.Collection.Find(ctx, bson.M{"user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	changes := []models.RatingChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/AbenezerWork/AASTU-CPC/models"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type SessionRepository struct {
@@ -18,6 +21,25 @@ func NewSessionRepository(db *mongo.Database) *SessionRepository {
 	}
 }
 
+// EnsureIndexes makes session lookups by token unique, indexes sessions by
+// user and lets Mongo delete sessions once they expire.
+func (r *SessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "session_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_seen_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
 func (r *SessionRepository) Create(ctx context.Context, session *models.Session) error {
 	_, err := r.Collection.InsertOne(ctx, session)
 	return err
@@ -29,13 +51,95 @@ func (r *SessionRepository) GetBySessionID(ctx context.Context, sessionID string
 	return &session, err
 }
 
-func (r *SessionRepository) GetByUserID(ctx context.Context, userID string) (*models.Session, error) {
+func (r *SessionRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*models.Session, error) {
 	var session models.Session
-	err := r.Collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&session)
+	err := r.Collection.FindOne(ctx, bson.M{"_id": id}).Decode(&session)
 	return &session, err
 }
 
-func (r *SessionRepository) Delete(ctx context.Context, sessionID string) error {
-	_, err := r.Collection.DeleteOne(ctx, bson.M{"session_id": sessionID})
+// ListActive returns a user's live sessions, most recently used first.
+func (r *SessionRepository) ListActive(ctx context.Context, userID primitive.ObjectID) ([]models.Session, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": bson.M{"$exists": false},
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}})
+	cursor, err := r.Collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	sessions := []models.Session{}
+	err = cursor.All(ctx, &sessions)
+	return sessions, err
+}
+
+// Touch records that a session was used and moves its expiry.
+func (r *SessionRepository) Touch(ctx context.Context, sessionID string, seen time.Time, expires time.Time) error {
+	_, err := r.Collection.UpdateOne(ctx,
+		bson.M{"session_id": sessionID},
+		bson.M{"$set": bson.M{"last_seen_at": seen, "expires_at": expires}},
+	)
+	return err
+}
+
+// Rotate replaces a live session whose SessionID is still old with session,
+// so that of two refreshes racing with the same token only one wins. It
+// returns mongo.ErrNoDocuments for the loser.
+func (r *SessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, old string, session *models.Session) error {
+	res, err := r.Collection.ReplaceOne(ctx,
+		bson.M{"_id": id, "session_id": old, "revoked_at": bson.M{"$exists": false}},
+		session,
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// SetRole updates the role recorded in a user's sessions after it changed.
+func (r *SessionRepository) SetRole(ctx context.Context, userID primitive.ObjectID, role string) error {
+	_, err := r.Collection.UpdateMany(ctx, bson.M{"user_id": userID}, bson.M{"$set": bson.M{"role": role}})
+	return err
+}
+
+// Revoke ends a session. It is kept until it expires so a stolen token is
+// recognised as revoked rather than unknown.
+func (r *SessionRepository) Revoke(ctx context.Context, sessionID string) error {
+	_, err := r.Collection.UpdateOne(ctx,
+		bson.M{"session_id": sessionID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
 	return err
 }
+
+// RevokeByID ends one of a user's live sessions. It returns
+// mongo.ErrNoDocuments if the user has no such session.
+func (r *SessionRepository) RevokeByID(ctx context.Context, userID, id primitive.ObjectID) error {
+	res, err := r.Collection.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// RevokeAll ends every session of a user and returns how many were live.
+func (r *SessionRepository) RevokeAll(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	res, err := r.Collection.UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
@@ -2,13 +2,20 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/AbenezerWork/AASTU-CPC/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrDuplicate is returned when a write would record the same judge
+// submission twice or credit a user twice for the same problem.
+var ErrDuplicate = errors.New("duplicate submission")
+
 type SubmissionRepository struct {
 	Collection *mongo.Collection
 }
@@ -19,11 +26,74 @@ func NewSubmissionRepository(db *mongo.Database) *SubmissionRepository {
 	}
 }
 
-func (r *SubmissionRepository) Create(ctx context.Context, submission *models.Submission) error {
-	_, err := r.Collection.InsertOne(ctx, submission)
+// EnsureIndexes creates the indexes the verification queue relies on and the
+// unique indexes that stop a judge submission from being credited twice.
+func (r *SubmissionRepository) EnsureIndexes(ctx context.Context) error {
+	// the first version of the judge submission index also covered rejected
+	// and failed records, which kept them from being submitted again
+	if _, err := r.Collection.Indexes().DropOne(ctx, "judge_1_submission_1"); err != nil && !indexNotFound(err) {
+		return err
+	}
+	_, err := r.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "verified_at", Value: 1}}},
+		{
+			// one live record per external submission, whoever sends it;
+			// rejected and failed ones may be submitted again. $in in a
+			// partial filter needs MongoDB 6.0.
+			Keys: bson.D{{Key: "judge", Value: 1}, {Key: "submission", Value: 1}},
+			Options: options.Index().SetName("judge_submission_live").SetUnique(true).
+				SetPartialFilterExpression(bson.M{
+					"submission": bson.M{"$gt": ""},
+					"status": bson.M{"$in": bson.A{
+						models.SubmissionPending, models.SubmissionVerifying, models.SubmissionAccepted,
+					}},
+				}),
+		},
+		{
+			// one accepted solve per user and problem
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "problem_id", Value: 1}},
+			Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": models.SubmissionAccepted}),
+		},
+	})
+	return err
+}
+
+// indexNotFound reports whether err is MongoDB's IndexNotFound or
+// NamespaceNotFound, as returned when dropping an index that is not there.
+func indexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && (cmdErr.Code == 27 || cmdErr.Code == 26)
+}
+
+// duplicate maps duplicate key errors to ErrDuplicate.
+func duplicate(err error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
 	return err
 }
 
+func (r *SubmissionRepository) Create(ctx context.Context, submission *models.Submission) error {
+	result, err := r.Collection.InsertOne(ctx, submission)
+	if err != nil {
+		return duplicate(err)
+	}
+	submission.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (r *SubmissionRepository) GetByID(ctx context.Context, id string) (*models.Submission, error) {
+	id_, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return &models.Submission{}, err
+	}
+	var submission models.Submission
+	err = r.Collection.FindOne(ctx, bson.M{"_id": id_}).Decode(&submission)
+	return &submission, err
+}
+
 func (r *SubmissionRepository) GetByProblemID(ctx context.Context, userID string) (*models.Submission, error) {
 	var submission models.Submission
 	err := r.Collection.FindOne(ctx, bson.M{"problem_id": userID}).Decode(&submission)
@@ -36,6 +106,94 @@ func (r *SubmissionRepository) GetByUserID(ctx context.Context, userID string) (
 	return &submission, err
 }
 
+// HasAccepted reports whether the user already has an accepted solve of problemID.
+func (r *SubmissionRepository) HasAccepted(ctx context.Context, userID string, problemID string) (bool, error) {
+	n, err := r.Collection.CountDocuments(ctx, bson.M{
+		"user_id":    userID,
+		"problem_id": problemID,
+		"status":     models.SubmissionAccepted,
+	}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+// IsFirstSolve reports whether nobody has an accepted solve of problemID yet.
+func (r *SubmissionRepository) IsFirstSolve(ctx context.Context, problemID string) (bool, error) {
+	n, err := r.Collection.CountDocuments(ctx, bson.M{
+		"problem_id": problemID,
+		"status":     models.SubmissionAccepted,
+	}, options.Count().SetLimit(1))
+	return n == 0, err
+}
+
+// Level returns the average difficulty of the user's last n rated solves, or
+// zero if there are none.
+func (r *SubmissionRepository) Level(ctx context.Context, userID string, n int) (int, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "verified_at", Value: -1}}).
+		SetLimit(int64(n)).
+		SetProjection(bson.M{"difficulty": 1})
+	cursor, err := r.Collection.Find(ctx, bson.M{
+		"user_id":    userID,
+		"status":     models.SubmissionAccepted,
+		"difficulty": bson.M{"$gt": 0},
+	}, opts)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var solves []models.Submission
+	if err := cursor.All(ctx, &solves); err != nil {
+		return 0, err
+	}
+	if len(solves) == 0 {
+		return 0, nil
+	}
+	sum := 0
+	for _, s := range solves {
+		sum += s.Difficulty
+	}
+	return sum / len(solves), nil
+}
+
+// AcceptedHistory returns every accepted submission in the order they were verified.
+func (r *SubmissionRepository) AcceptedHistory(ctx context.Context) ([]models.Submission, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "verified_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.Collection.Find(ctx, bson.M{"status": models.SubmissionAccepted}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var submissions []models.Submission
+	if err := cursor.All(ctx, &submissions); err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// MarkAccepted stores the accepted status and scoring fields of submission.
+func (r *SubmissionRepository) MarkAccepted(ctx context.Context, submission *models.Submission) error {
+	_, err := r.Collection.UpdateByID(ctx, submission.ID, bson.M{"$set": bson.M{
+		"status":      submission.Status,
+		"error":       submission.Error,
+		"difficulty":  submission.Difficulty,
+		"points":      submission.Points,
+		"verified_at": submission.VerifiedAt,
+		"updated_at":  submission.UpdatedAt,
+	}})
+	return duplicate(err)
+}
+
+// SetPoints overwrites the scoring fields of a submission.
+func (r *SubmissionRepository) SetPoints(ctx context.Context, id primitive.ObjectID, difficulty int, points int64) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"difficulty": difficulty,
+		"points":     points,
+	}})
+	return err
+}
+
 func (r *SubmissionRepository) Delete(ctx context.Context, submissionID string) error {
 	id, err := primitive.ObjectIDFromHex(submissionID)
 	if err != nil {
@@ -44,3 +202,128 @@ func (r *SubmissionRepository) Delete(ctx context.Context, submissionID string)
 	_, err = r.Collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
+
+// ClaimNext marks the oldest pending submission to an external judge that is
+// due as verifying and returns it. It returns mongo.ErrNoDocuments when there
+// is nothing to do. Native submissions are queued as judge jobs instead.
+func (r *SubmissionRepository) ClaimNext(ctx context.Context) (*models.Submission, error) {
+	now := time.Now()
+	filter := bson.M{
+		"judge":           bson.M{"$ne": models.JudgeNative},
+		"status":          models.SubmissionPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": models.SubmissionVerifying, "updated_at": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var submission models.Submission
+	err := r.Collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&submission)
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// Finish records the final status of a submission. Accepting a second solve
+// of the same problem by the same user returns ErrDuplicate.
+func (r *SubmissionRepository) Finish(ctx context.Context, id primitive.ObjectID, status string, errMsg string) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"status":     status,
+		"error":      errMsg,
+		"updated_at": time.Now(),
+	}})
+	return duplicate(err)
+}
+
+// StartJudging marks a native submission as being judged on total tests
+// and clears the results of any earlier attempt.
+func (r *SubmissionRepository) StartJudging(ctx context.Context, id primitive.ObjectID, total int) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"status":         models.SubmissionVerifying,
+		"verdict":        "",
+		"compile_output": "",
+		"tests":          []models.TestResult{},
+		"tests_total":    total,
+		"updated_at":     time.Now(),
+	}})
+	return err
+}
+
+// AddTestResult appends the result of one test while judging is under way.
+func (r *SubmissionRepository) AddTestResult(ctx context.Context, id primitive.ObjectID, result models.TestResult) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$push": bson.M{"tests": result},
+		"$set":  bson.M{"updated_at": time.Now()},
+	})
+	return err
+}
+
+// UnjudgedNative returns the ids of native submissions that have not been
+// judged yet.
+func (r *SubmissionRepository) UnjudgedNative(ctx context.Context) ([]primitive.ObjectID, error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{
+		"judge":  models.JudgeNative,
+		"status": bson.M{"$in": bson.A{models.SubmissionPending, models.SubmissionVerifying}},
+	}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID)
+	}
+	return ids, cursor.Err()
+}
+
+// SaveJudgement stores the verdict of the built-in judge; the status is set
+// separately by Finish or the scorer.
+func (r *SubmissionRepository) SaveJudgement(ctx context.Context, id primitive.ObjectID, verdict string, compileOutput string, tests []models.TestResult) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"verdict":        verdict,
+		"compile_output": compileOutput,
+		"tests":          tests,
+		"updated_at":     time.Now(),
+	}})
+	return err
+}
+
+// Retry puts a submission back in the queue to be tried again at next.
+func (r *SubmissionRepository) Retry(ctx context.Context, id primitive.ObjectID, next time.Time, errMsg string) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{
+		"status":          models.SubmissionPending,
+		"error":           errMsg,
+		"next_attempt_at": next,
+		"updated_at":      time.Now(),
+	}})
+	return err
+}
+
+// RequeueStale returns submissions stuck in verifying since before cutoff to
+// the queue, e.g. because the server was restarted mid-verification.
+func (r *SubmissionRepository) RequeueStale(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.Collection.UpdateMany(ctx,
+		bson.M{
+			"judge":      bson.M{"$ne": models.JudgeNative},
+			"status":     models.SubmissionVerifying,
+			"updated_at": bson.M{"$lt": cutoff},
+		},
+		bson.M{"$set": bson.M{"status": models.SubmissionPending, "next_attempt_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
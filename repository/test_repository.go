@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TestRepository stores test data of native problems: the group layout in
+// the problem_tests collection and the files in the "testdata" GridFS bucket.
+type TestRepository struct {
+	Collection *mongo.Collection
+	Bucket     *gridfs.Bucket
+}
+
+func NewTestRepository(db *mongo.Database) (*TestRepository, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName("testdata"))
+	if err != nil {
+		return nil, err
+	}
+	return &TestRepository{
+		Collection: db.Collection("problem_tests"),
+		Bucket:     bucket,
+	}, nil
+}
+
+// EnsureIndexes keeps one test layout per problem.
+func (r *TestRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "problem_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// Get returns the tests of a problem; a problem without tests yields an
+// empty layout.
+func (r *TestRepository) Get(ctx context.Context, problemID primitive.ObjectID) (*models.ProblemTests, error) {
+	var tests models.ProblemTests
+	err := r.Collection.FindOne(ctx, bson.M{"problem_id": problemID}).Decode(&tests)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return &models.ProblemTests{ProblemID: problemID, Groups: []models.TestGroup{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tests, nil
+}
+
+// ErrTestsChanged is returned by Save when the tests were saved by someone
+// else since they were read.
+var ErrTestsChanged = errors.New("tests were changed concurrently")
+
+// Save replaces the test layout of a problem if it is still at the version
+// it was read at, and moves it to the next version.
+func (r *TestRepository) Save(ctx context.Context, tests *models.ProblemTests) error {
+	read := tests.Version
+	filter := bson.M{"problem_id": tests.ProblemID, "version": read}
+	if read == 0 {
+		// layouts saved before versioning have no version field
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+
+	tests.Version = read + 1
+	tests.UpdatedAt = time.Now()
+	res, err := r.Collection.ReplaceOne(ctx, filter, tests, options.Replace().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// the problem has tests at another version, so the upsert collided
+		// with them on the problem_id index
+		err = ErrTestsChanged
+	}
+	if err != nil {
+		tests.Version = read
+		return err
+	}
+	if res.UpsertedID != nil {
+		if id, ok := res.UpsertedID.(primitive.ObjectID); ok {
+			tests.ID = id
+		}
+	}
+	return nil
+}
+
+// UploadFile stores a test file and returns its id, SHA-256 and size.
+func (r *TestRepository) UploadFile(ctx context.Context, name string, data io.Reader) (primitive.ObjectID, string, int64, error) {
+	stream, err := r.Bucket.OpenUploadStream(name)
+	if err != nil {
+		return primitive.NilObjectID, "", 0, err
+	}
+	defer stream.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetWriteDeadline(deadline)
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(stream, hash), data)
+	if err != nil {
+		stream.Abort()
+		return primitive.NilObjectID, "", 0, err
+	}
+	if err := stream.Close(); err != nil {
+		return primitive.NilObjectID, "", 0, err
+	}
+	return stream.FileID.(primitive.ObjectID), hex.EncodeToString(hash.Sum(nil)), size, nil
+}
+
+// OpenFile returns a reader for a test file.
+func (r *TestRepository) OpenFile(ctx context.Context, id primitive.ObjectID) (io.ReadCloser, error) {
+	stream, err := r.Bucket.OpenDownloadStream(id)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetReadDeadline(deadline)
+	}
+	return stream, nil
+}
+
+// DeleteFiles removes the files of the given tests, ignoring files that are
+// already gone.
+func (r *TestRepository) DeleteFiles(ctx context.Context, tests []models.TestCase) error {
+	for _, t := range tests {
+		for _, id := range []primitive.ObjectID{t.InputFileID, t.OutputFileID} {
+			if err := r.Bucket.DeleteContext(ctx, id); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Delete removes the tests of a problem together with their files.
+func (r *TestRepository) Delete(ctx context.Context, problemID primitive.ObjectID) error {
+	tests, err := r.Get(ctx, problemID)
+	if err != nil {
+		return err
+	}
+	for _, g := range tests.Groups {
+		if err := r.DeleteFiles(ctx, g.Tests); err != nil {
+			return err
+		}
+	}
+	_, err = r.Collection.DeleteOne(ctx, bson.M{"problem_id": problemID})
+	return err
+}
@@ -2,13 +2,23 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/AbenezerWork/AASTU-CPC/models"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrHandleTaken is returned when a handle is already verified by another
+// user.
+var ErrHandleTaken = errors.New("handle verified by another user")
+
+// handleCollation compares handles case-insensitively, as the judges do.
+var handleCollation = &options.Collation{Locale: "en", Strength: 2}
+
 type UserRepository struct {
 	Collection *mongo.Collection
 }
@@ -19,6 +29,46 @@ func NewUserRepository(db *mongo.Database) *UserRepository {
 	}
 }
 
+// accountField names the fields holding a user's account on a judge.
+type accountField struct {
+	handle, verified, challenge string
+}
+
+// accounts are the judges linked through the generic account endpoints,
+// see models.User.Account.
+var accounts = map[string]accountField{
+	"atcoder":  {handle: "atcoder_username", verified: "atcoder_verified", challenge: "atcoder_challenge"},
+	"cses":     {handle: "cses_user_id", verified: "cses_verified", challenge: "cses_challenge"},
+	"leetcode": {handle: "leetcode_username", verified: "leetcode_verified", challenge: "leetcode_challenge"},
+}
+
+func account(judge string) (accountField, error) {
+	fields, ok := accounts[judge]
+	if !ok {
+		return accountField{}, fmt.Errorf("no %s account on users", judge)
+	}
+	return fields, nil
+}
+
+// EnsureIndexes creates the unique indexes that let a judge handle be
+// verified by one user only, whatever its case.
+func (r *UserRepository) EnsureIndexes(ctx context.Context) error {
+	fields := []accountField{{handle: "codeforces_username", verified: "codeforces_verified"}}
+	for _, f := range accounts {
+		fields = append(fields, f)
+	}
+	var indexes []mongo.IndexModel
+	for _, f := range fields {
+		indexes = append(indexes, mongo.IndexModel{
+			Keys: bson.D{{Key: f.handle, Value: 1}},
+			Options: options.Index().SetUnique(true).SetCollation(handleCollation).
+				SetPartialFilterExpression(bson.M{f.verified: true}),
+		})
+	}
+	_, err := r.Collection.Indexes().CreateMany(ctx, indexes)
+	return err
+}
+
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 	_, err := r.Collection.InsertOne(ctx, user)
 	return err
@@ -40,12 +90,167 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*m
 	return &user, err
 }
 
-func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
-	_, err := r.Collection.ReplaceOne(ctx, bson.M{"_id": user.ID}, user)
+// UpdateProfile writes the fields of user that an admin edits. Whether a
+// handle is verified, pending challenges and the solve sync's position are
+// kept from old, the stored user, except that a changed handle is no longer
+// verified.
+func (r *UserRepository) UpdateProfile(ctx context.Context, old, user *models.User) error {
+	_, err := r.Collection.UpdateByID(ctx, old.ID, profileUpdate(old, user))
 	return err
 }
 
+// profileUpdate returns the update document of UpdateProfile.
+func profileUpdate(old, user *models.User) bson.M {
+	set := bson.M{
+		"score":               user.Score,
+		"role":                user.Role,
+		"mentor":              user.Mentor,
+		"division":            user.Division,
+		"user_name":           user.UserName,
+		"codeforces_username": user.CodeforcesUsername,
+		"atcoder_username":    user.AtCoderUsername,
+		"cses_user_id":        user.CSESUserID,
+		"leetcode_username":   user.LeetCodeUsername,
+	}
+	if user.PasswordHash != "" {
+		set["password"] = user.PasswordHash
+	}
+	unset := bson.M{}
+	handles := []struct {
+		old, new, prefix string
+	}{
+		{old.CodeforcesUsername, user.CodeforcesUsername, "codeforces"},
+		{old.AtCoderUsername, user.AtCoderUsername, "atcoder"},
+		{old.CSESUserID, user.CSESUserID, "cses"},
+		{old.LeetCodeUsername, user.LeetCodeUsername, "leetcode"},
+	}
+	for _, h := range handles {
+		if h.old != h.new {
+			set[h.prefix+"_verified"] = false
+			unset[h.prefix+"_challenge"] = ""
+		}
+	}
+	if old.CodeforcesUsername != user.CodeforcesUsername {
+		// the new handle's submissions are synced from the start
+		set["codeforces_last_submission_id"] = int64(0)
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	return update
+}
+
 func (r *UserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
 	_, err := r.Collection.DeleteOne(ctx, bson.M{"_id": id})
 	return err
 }
+
+// SetCodeforcesChallenge stores the handle challenge a user has to complete.
+func (r *UserRepository) SetCodeforcesChallenge(ctx context.Context, id primitive.ObjectID, challenge *models.HandleChallenge) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"codeforces_challenge": challenge}})
+	return err
+}
+
+// GetByVerifiedCodeforcesHandle returns the user that proved ownership of handle.
+func (r *UserRepository) GetByVerifiedCodeforcesHandle(ctx context.Context, handle string) (*models.User, error) {
+	var user models.User
+	err := r.Collection.FindOne(ctx, bson.M{"codeforces_username": handle, "codeforces_verified": true},
+		options.FindOne().SetCollation(handleCollation)).Decode(&user)
+	return &user, err
+}
+
+// MarkCodeforcesVerified records handle as verified for the user and clears
+// the completed challenge. It returns ErrHandleTaken if another user has
+// verified the handle.
+func (r *UserRepository) MarkCodeforcesVerified(ctx context.Context, id primitive.ObjectID, handle string) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set":   bson.M{"codeforces_username": handle, "codeforces_verified": true},
+		"$unset": bson.M{"codeforces_challenge": ""},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrHandleTaken
+	}
+	return err
+}
+
+// SetAccountChallenge stores the challenge a user has to complete to prove
+// they own their account on judge.
+func (r *UserRepository) SetAccountChallenge(ctx context.Context, id primitive.ObjectID, judge string, challenge *models.HandleChallenge) error {
+	f, err := account(judge)
+	if err != nil {
+		return err
+	}
+	_, err = r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{f.challenge: challenge}})
+	return err
+}
+
+// GetByVerifiedAccount returns the user that proved they own handle on judge.
+func (r *UserRepository) GetByVerifiedAccount(ctx context.Context, judge, handle string) (*models.User, error) {
+	f, err := account(judge)
+	if err != nil {
+		return nil, err
+	}
+	var user models.User
+	err = r.Collection.FindOne(ctx, bson.M{f.handle: handle, f.verified: true},
+		options.FindOne().SetCollation(handleCollation)).Decode(&user)
+	return &user, err
+}
+
+// MarkAccountVerified records handle as the user's verified account on
+// judge and clears the completed challenge. It returns ErrHandleTaken if
+// another user has verified the handle.
+func (r *UserRepository) MarkAccountVerified(ctx context.Context, id primitive.ObjectID, judge, handle string) error {
+	f, err := account(judge)
+	if err != nil {
+		return err
+	}
+	_, err = r.Collection.UpdateByID(ctx, id, bson.M{
+		"$set":   bson.M{f.handle: handle, f.verified: true},
+		"$unset": bson.M{f.challenge: ""},
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrHandleTaken
+	}
+	return err
+}
+
+// AddScore adds delta to the user's score.
+func (r *UserRepository) AddScore(ctx context.Context, id primitive.ObjectID, delta int64) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$inc": bson.M{"score": delta}})
+	return err
+}
+
+// SetScores sets the score of every user in scores and resets everybody else to zero.
+func (r *UserRepository) SetScores(ctx context.Context, scores map[primitive.ObjectID]int64) error {
+	if _, err := r.Collection.UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"score": 0}}); err != nil {
+		return err
+	}
+	for id, score := range scores {
+		if _, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"score": score}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetVerifiedCodeforcesUsers returns every user with a verified Codeforces handle.
+func (r *UserRepository) GetVerifiedCodeforcesUsers(ctx context.Context) ([]models.User, error) {
+	cursor, err := r.Collection.Find(ctx, bson.M{"codeforces_verified": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SetCodeforcesLastSubmissionID records the newest submission the solve sync has seen.
+func (r *UserRepository) SetCodeforcesLastSubmissionID(ctx context.Context, id primitive.ObjectID, last int64) error {
+	_, err := r.Collection.UpdateByID(ctx, id, bson.M{"$set": bson.M{"codeforces_last_submission_id": last}})
+	return err
+}
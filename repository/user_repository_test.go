@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestProfileUpdateKeepsVerification(t *testing.T) {
+	old := &models.User{CodeforcesUsername: "tourist", CodeforcesVerified: true, AtCoderUsername: "old"}
+	// a client claiming every handle verified and renaming one
+	user := &models.User{
+		CodeforcesUsername: "tourist",
+		CodeforcesVerified: true,
+		AtCoderUsername:    "new",
+		AtCoderVerified:    true,
+		CSESVerified:       true,
+		LeetCodeVerified:   true,
+	}
+	update := profileUpdate(old, user)
+	set := update["$set"].(bson.M)
+	for _, field := range []string{"codeforces_verified", "cses_verified", "leetcode_verified", "codeforces_last_submission_id", "password"} {
+		if v, ok := set[field]; ok {
+			t.Errorf("%s set to %v", field, v)
+		}
+	}
+	if set["atcoder_verified"] != false || set["atcoder_username"] != "new" {
+		t.Errorf("renamed handle: %v", set)
+	}
+	if unset, _ := update["$unset"].(bson.M); len(unset) != 1 || unset["atcoder_challenge"] == nil {
+		t.Errorf("unset = %v, want only the atcoder challenge", update["$unset"])
+	}
+}
@@ -3,9 +3,9 @@ package routers
 import (
 	_ "github.com/AbenezerWork/AASTU-CPC/docs"
 
+	"github.com/AbenezerWork/AASTU-CPC/auth"
 	"github.com/AbenezerWork/AASTU-CPC/controllers"
 	"github.com/AbenezerWork/AASTU-CPC/middleware"
-	"github.com/AbenezerWork/AASTU-CPC/repository"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -20,34 +20,64 @@ import (
 
 // @securityDefinitions.apikey Auth
 // @in header
-// @name Set-Cookie
-// @description Authentication cookie for regular users
+// @name Cookie
+// @description Session cookie (aastu_session) for regular users
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer " followed by an access token from POST /token; accepted wherever Auth or AdminAuth is
 
 // @securityDefinitions.apikey AdminAuth
 // @in header
-// @name Set-Cookie
-// @description Authentication cookie for admin users
+// @name Cookie
+// @description Session cookie (aastu_session) of a user whose role grants the route's permission, e.g. user:manage
 
-func SetupRouter(articleCtrl *controllers.ArticleController, problemCtrl *controllers.ProblemController, authCtrl *controllers.AuthController, sessionRepo *repository.SessionRepository, submissionController *controllers.SubmissionController) *gin.Engine {
+func SetupRouter(articleCtrl *controllers.ArticleController, problemCtrl *controllers.ProblemController, authCtrl *controllers.AuthController, sessions *auth.Manager, submissionController *controllers.SubmissionController, codeforcesCtrl *controllers.CodeforcesController, leaderboardCtrl *controllers.LeaderboardController, importCtrl *controllers.ImportController, problemSetCtrl *controllers.ProblemSetController, ratingCtrl *controllers.RatingController, testCtrl *controllers.TestController, accountCtrl *controllers.AccountController) *gin.Engine {
 	r := gin.Default()
 
 	// Public routes
 	r.GET("/articles/:id", articleCtrl.GetArticleByID)
 	r.GET("/articles", articleCtrl.GetArticles)
-	r.GET("problems/:id", problemCtrl.GetProblemByID)
+	r.GET("problems/:id", middleware.AuthRequired(sessions), problemCtrl.GetProblemByID)
 	r.GET("problems", problemCtrl.GetProblems)
+	r.GET("/problemsets/:id", problemSetCtrl.GetProblemSetByID)
+	r.GET("/problemsets", problemSetCtrl.GetProblemSets)
+	r.GET("/leaderboard", leaderboardCtrl.GetLeaderboard)
+	r.GET("/languages", submissionController.GetLanguages)
 
 	// Auth routes
 	r.POST("/signup", authCtrl.Signup)
 	r.POST("/login", authCtrl.Login)
+	r.POST("/token", authCtrl.Token)
 	r.POST("/logout", authCtrl.Logout)
 
 	//submission
-	r.POST("validate-submission", submissionController.ValidateSubmission)
+	submissions := r.Group("/")
+	submissions.Use(middleware.AuthRequired(sessions))
+	{
+		submissions.POST("validate-submission", submissionController.ValidateSubmission)
+		submissions.GET("submissions/:id", submissionController.GetSubmission)
+	}
+
+	r.GET("/users/:id/rating-history", middleware.AuthRequired(sessions), ratingCtrl.GetRatingHistory)
+
+	// Account routes
+	me := r.Group("/me")
+	me.Use(middleware.AuthRequired(sessions))
+	{
+		me.POST("/codeforces/challenge", codeforcesCtrl.IssueChallenge)
+		me.POST("/codeforces/verify", codeforcesCtrl.VerifyChallenge)
+		me.POST("/accounts/:judge/challenge", accountCtrl.IssueChallenge)
+		me.POST("/accounts/:judge/verify", accountCtrl.VerifyChallenge)
+		me.GET("/sessions", authCtrl.GetSessions)
+		me.DELETE("/sessions", authCtrl.DeleteSessions)
+		me.DELETE("/sessions/:id", authCtrl.DeleteSession)
+	}
 
 	// User routes
 	users := r.Group("/users")
-	users.Use(middleware.AdminAuthRequired(sessionRepo))
+	users.Use(middleware.RequirePermission(sessions, auth.PermUserManage))
 	{
 		users.POST("/", authCtrl.CreateUser)
 		users.GET("/:id", authCtrl.GetUserByID)
@@ -57,19 +87,51 @@ func SetupRouter(articleCtrl *controllers.ArticleController, problemCtrl *contro
 
 	// Problem routes
 	problems := r.Group("/problemsedit")
-	problems.Use(middleware.AuthRequired(sessionRepo))
+	problems.Use(middleware.RequirePermission(sessions, auth.PermProblemCreate))
 	{
 		problems.POST("/", problemCtrl.CreateProblem)
 		problems.PUT("/:id", problemCtrl.UpdateProblem)
 		problems.DELETE("/:id", problemCtrl.DeleteProblem)
 	}
+	tests := r.Group("/problemsedit/:id/tests")
+	tests.Use(middleware.RequirePermission(sessions, auth.PermProblemCreate))
+	{
+		tests.GET("", testCtrl.GetTests)
+		tests.PUT("/order", testCtrl.ReorderGroups)
+		tests.POST("/groups", testCtrl.CreateGroup)
+		tests.PUT("/groups/:group", testCtrl.UpdateGroup)
+		tests.DELETE("/groups/:group", testCtrl.DeleteGroup)
+		tests.POST("/groups/:group/tests", testCtrl.UploadTests)
+		tests.PUT("/groups/:group/tests/:test", testCtrl.UpdateTest)
+		tests.DELETE("/groups/:group/tests/:test", testCtrl.DeleteTest)
+		tests.GET("/files/:file", testCtrl.DownloadFile)
+	}
 	articles := r.Group("/articlesedit")
-	articles.Use(middleware.AuthRequired(sessionRepo))
+	articles.Use(middleware.RequirePermission(sessions, auth.PermArticleCreate))
 	{
 		articles.POST("/", articleCtrl.CreateArticle)
 		articles.PUT("/:id", articleCtrl.UpdateArticle)
 		articles.DELETE("/:id", articleCtrl.DeleteArticle)
 	}
+	r.PUT("/articlesedit/:id/publish", middleware.RequirePermission(sessions, auth.PermArticlePublish), articleCtrl.PublishArticle)
+	problemSets := r.Group("/problemsetsedit")
+	problemSets.Use(middleware.RequirePermission(sessions, auth.PermProblemSetManage))
+	{
+		problemSets.POST("/", problemSetCtrl.CreateProblemSet)
+		problemSets.PUT("/:id", problemSetCtrl.UpdateProblemSet)
+		problemSets.DELETE("/:id", problemSetCtrl.DeleteProblemSet)
+	}
+
+	// Import routes
+	imports := r.Group("/import")
+	imports.Use(middleware.RequirePermission(sessions, auth.PermImportRun))
+	{
+		imports.POST("/codeforces/problemset", importCtrl.ImportCodeforcesProblemset)
+		imports.POST("/codeforces/contest", importCtrl.ImportCodeforcesContest)
+		imports.POST("/cses", importCtrl.ImportCSES)
+	}
+
+	r.GET("/codeforces/stats", middleware.RequirePermission(sessions, auth.PermStatsView), codeforcesCtrl.Stats)
 
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
@@ -0,0 +1,324 @@
+// Package sandbox runs untrusted programs under resource limits.
+//
+// Limits are applied with setrlimit through a small shell wrapper, so the
+// program itself is exec'd with the caps already in place. This bounds CPU
+// time, address space, stack, processes and file size. With an Isolation
+// the program also runs as an unprivileged user of its own, so it cannot
+// read the server's files or environment, and in a network namespace with
+// nothing but a loopback interface that is down.
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Outcomes of a run.
+const (
+	OK           = "ok"
+	TimeLimit    = "time-limit"
+	MemoryLimit  = "memory-limit"
+	OutputLimit  = "output-limit"
+	RuntimeError = "runtime-error"
+)
+
+const defaultOutputSize = 64 << 20
+
+// Limits bounds a run. Zero values leave the resource unbounded, except
+// Output, which defaults to 64 MiB.
+type Limits struct {
+	CPUTime  time.Duration
+	WallTime time.Duration
+	// Memory is checked against the peak resident set size after the run.
+	Memory int64
+	// AddressSpace also caps virtual memory at Memory, so allocations fail
+	// instead of succeeding and being judged afterwards. Runtimes that
+	// reserve large heaps up front (Java, Go) must run without it.
+	AddressSpace bool
+	// VirtualMemory caps virtual memory at a size of its own, for those
+	// runtimes and for compilers. It takes precedence over AddressSpace.
+	VirtualMemory int64
+	// Stack raises the stack limit, which recursive solutions need.
+	Stack  int64
+	Output int64
+	// Processes caps the number of processes and threads of the user the
+	// program runs as, against fork bombs. It counts every process of that
+	// user, so it is only applied with an Isolation.
+	Processes int
+	// FileSize caps the size of any file the program writes.
+	FileSize int64
+}
+
+// Isolation confines a run to an unprivileged user without network access.
+// Setting it requires running as root.
+type Isolation struct {
+	// UID and GID the program runs as. They should belong to a user that
+	// owns no files and no other processes.
+	UID, GID uint32
+}
+
+// LookupUser returns the isolation of running as the named user, who must
+// not be root or the user we run as.
+func LookupUser(name string) (*Isolation, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, err
+	}
+	if uid == 0 || int(uid) == os.Getuid() {
+		return nil, fmt.Errorf("sandbox: user %s is not unprivileged", name)
+	}
+	return &Isolation{UID: uint32(uid), GID: uint32(gid)}, nil
+}
+
+// Own hands path to the isolated user, for directories a run writes to.
+// It does nothing for a nil Isolation.
+func (iso *Isolation) Own(path string) error {
+	if iso == nil {
+		return nil
+	}
+	return os.Chown(path, int(iso.UID), int(iso.GID))
+}
+
+// Cmd describes a program to run.
+type Cmd struct {
+	Args []string
+	Dir  string
+	// Env is the program's whole environment; nothing is inherited.
+	Env   []string
+	Stdin io.Reader
+	// Stdout receives the output instead of Result.Stdout, e.g. a pipe to
+	// an interactor. The output limit does not apply to it.
+	Stdout io.Writer
+	Limits Limits
+	// Isolation, if set, runs the program as another user without network.
+	Isolation *Isolation
+}
+
+// Result describes a finished run.
+type Result struct {
+	Status   string
+	ExitCode int
+	Signal   syscall.Signal
+	CPUTime  time.Duration
+	WallTime time.Duration
+	Memory   int64 // peak resident set size in bytes
+	Stdout   []byte
+	Stderr   []byte
+}
+
+// wrapper applies the limits passed as $1..$5 before exec'ing the program.
+// Failing to raise the stack limit is not fatal; failing to lower any other
+// limit is. dash calls the process limit -p, other shells -u.
+const wrapper = `cpu=$1 mem=$2 stack=$3 nproc=$4 fsize=$5; shift 5
+if [ "$cpu" -gt 0 ]; then ulimit -t "$cpu" || exit 126; fi
+if [ "$mem" -gt 0 ]; then ulimit -v "$mem" || exit 126; fi
+if [ "$stack" -gt 0 ]; then ulimit -s "$stack" 2>/dev/null; fi
+if [ "$nproc" -gt 0 ]; then ulimit -u "$nproc" 2>/dev/null || ulimit -p "$nproc" || exit 126; fi
+if [ "$fsize" -gt 0 ]; then ulimit -f "$fsize" || exit 126; fi
+exec "$@"`
+
+// Run runs cmd and waits for it. The error is only set when the program
+// could not be run at all; limits being hit are reported in the Result.
+func Run(ctx context.Context, cmd Cmd) (*Result, error) {
+	if len(cmd.Args) == 0 {
+		return nil, errors.New("sandbox: no program")
+	}
+	limits := cmd.Limits
+	if limits.Output <= 0 {
+		limits.Output = defaultOutputSize
+	}
+
+	if limits.WallTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.WallTime)
+		defer cancel()
+	}
+
+	var cpu, mem, stack, fsize int64
+	var nproc int
+	if limits.CPUTime > 0 {
+		// whole seconds, rounded up; the exact limit is checked below
+		cpu = int64((limits.CPUTime + time.Second - 1) / time.Second)
+	}
+	switch {
+	case limits.VirtualMemory > 0:
+		mem = limits.VirtualMemory / 1024
+	case limits.AddressSpace && limits.Memory > 0:
+		mem = limits.Memory / 1024
+	}
+	if limits.Stack > 0 {
+		stack = limits.Stack / 1024
+	}
+	if cmd.Isolation != nil {
+		nproc = limits.Processes
+	}
+	if limits.FileSize > 0 {
+		// ulimit -f counts 512-byte blocks
+		fsize = max(limits.FileSize/512, 1)
+	}
+	args := append([]string{"-c", wrapper, "sandbox",
+		strconv.FormatInt(cpu, 10), strconv.FormatInt(mem, 10), strconv.FormatInt(stack, 10),
+		strconv.Itoa(nproc), strconv.FormatInt(fsize, 10)},
+		cmd.Args...)
+
+	c := exec.CommandContext(ctx, "/bin/sh", args...)
+	c.Dir = cmd.Dir
+	// a nil Env would pass on ours, secrets included
+	c.Env = append([]string{}, cmd.Env...)
+	c.Stdin = cmd.Stdin
+	// kill the whole process group, not just the shell's pid
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if iso := cmd.Isolation; iso != nil {
+		c.SysProcAttr.Credential = &syscall.Credential{Uid: iso.UID, Gid: iso.GID, Groups: []uint32{}}
+		c.SysProcAttr.Cloneflags = syscall.CLONE_NEWNET
+	}
+	c.Cancel = func() error { return syscall.Kill(-c.Process.Pid, syscall.SIGKILL) }
+	c.WaitDelay = time.Second
+
+	stdout := &capWriter{limit: limits.Output}
+	stderr := &capWriter{limit: 64 << 10, truncate: true}
+	c.Stdout = stdout
+	if cmd.Stdout != nil {
+		c.Stdout = cmd.Stdout
+	}
+	c.Stderr = stderr
+	stdout.kill = func() { syscall.Kill(-c.Process.Pid, syscall.SIGKILL) }
+
+	baseline := selfMaxRSS()
+	start := time.Now()
+	if err := c.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	polled := make(chan int64, 1)
+	go watchMemory(c.Process.Pid, done, polled)
+	err := c.Wait()
+	wall := time.Since(start)
+	close(done)
+	syscall.Kill(-c.Process.Pid, syscall.SIGKILL) // stray children
+
+	state := c.ProcessState
+	if state == nil {
+		return nil, err
+	}
+	result := &Result{
+		ExitCode: state.ExitCode(),
+		CPUTime:  state.UserTime() + state.SystemTime(),
+		WallTime: wall,
+		Stdout:   stdout.buf.Bytes(),
+		Stderr:   stderr.buf.Bytes(),
+	}
+	if ws, ok := state.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		result.Signal = ws.Signal()
+	}
+	result.Memory = <-polled
+	if ru, ok := state.SysUsage().(*syscall.Rusage); ok && ru.Maxrss*1024 > baseline {
+		// the child starts out sharing our memory (vfork), so its maxrss
+		// is only its own once it exceeds our peak
+		result.Memory = ru.Maxrss * 1024
+	}
+
+	switch {
+	case stdout.exceeded:
+		result.Status = OutputLimit
+	case limits.CPUTime > 0 && (result.CPUTime > limits.CPUTime || result.Signal == syscall.SIGXCPU):
+		result.Status = TimeLimit
+	case ctx.Err() != nil:
+		result.Status = TimeLimit
+	case limits.Memory > 0 && result.Memory > limits.Memory:
+		result.Status = MemoryLimit
+	case result.ExitCode != 0 || result.Signal != 0:
+		result.Status = RuntimeError
+	default:
+		result.Status = OK
+	}
+	return result, nil
+}
+
+// capWriter collects output up to limit bytes. Past the limit it either
+// drops the rest or kills the program.
+type capWriter struct {
+	buf      bytes.Buffer
+	limit    int64
+	truncate bool
+	exceeded bool
+	kill     func()
+}
+
+func (w *capWriter) Write(p []byte) (int, error) {
+	room := w.limit - int64(w.buf.Len())
+	if int64(len(p)) <= room {
+		return w.buf.Write(p)
+	}
+	w.buf.Write(p[:max(room, 0)])
+	if w.truncate {
+		return len(p), nil
+	}
+	if !w.exceeded {
+		w.exceeded = true
+		w.kill()
+	}
+	return 0, errors.New("output limit exceeded")
+}
+
+func selfMaxRSS() int64 {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return ru.Maxrss * 1024
+}
+
+// watchMemory samples the peak resident set size of pid until done is
+// closed and sends the last value seen. It measures programs that stay
+// below our own peak, which rusage cannot tell apart.
+func watchMemory(pid int, done <-chan struct{}, peak chan<- int64) {
+	path := "/proc/" + strconv.Itoa(pid) + "/status"
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	var highest int64
+	for {
+		if hwm := readHWM(path); hwm > highest {
+			highest = hwm
+		}
+		select {
+		case <-done:
+			peak <- highest
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readHWM returns VmHWM from a /proc status file in bytes.
+func readHWM(path string) int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if v, ok := strings.CutPrefix(line, "VmHWM:"); ok {
+			kb, _ := strconv.ParseInt(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "kB")), 10, 64)
+			return kb * 1024
+		}
+	}
+	return 0
+}
@@ -0,0 +1,162 @@
+package sandbox
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func run(t *testing.T, cmd Cmd) *Result {
+	t.Helper()
+	if cmd.Dir == "" {
+		cmd.Dir = workDir(t)
+		if err := cmd.Isolation.Own(cmd.Dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := Run(context.Background(), cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestRunLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		limits Limits
+		want   string
+	}{
+		{"ok", []string{"echo", "hi"}, Limits{}, OK},
+		{"exit code", []string{"sh", "-c", "exit 3"}, Limits{}, RuntimeError},
+		{"cpu time", []string{"sh", "-c", "while :; do :; done"}, Limits{CPUTime: 500 * time.Millisecond, WallTime: 10 * time.Second}, TimeLimit},
+		{"wall time", []string{"sleep", "10"}, Limits{WallTime: 200 * time.Millisecond}, TimeLimit},
+		{"output", []string{"head", "-c", "100000", "/dev/zero"}, Limits{Output: 1000}, OutputLimit},
+		{"file size", []string{"sh", "-c", "exec head -c 100000 /dev/zero > out"}, Limits{FileSize: 4096}, RuntimeError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := run(t, Cmd{Args: tt.args, Limits: tt.limits})
+			if res.Status != tt.want {
+				t.Errorf("status = %s, want %s (exit %d, signal %v)", res.Status, tt.want, res.ExitCode, res.Signal)
+			}
+		})
+	}
+}
+
+func TestRunVirtualMemory(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		want   string
+	}{
+		{"unbounded", Limits{Memory: 64 << 20}, "unlimited"},
+		{"address space", Limits{Memory: 64 << 20, AddressSpace: true}, "65536"},
+		{"virtual memory", Limits{Memory: 64 << 20, VirtualMemory: 1 << 30}, "1048576"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := run(t, Cmd{Args: []string{"sh", "-c", "ulimit -v"}, Limits: tt.limits})
+			if got := strings.TrimSpace(string(res.Stdout)); got != tt.want {
+				t.Errorf("ulimit -v = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunStdio(t *testing.T) {
+	res := run(t, Cmd{Args: []string{"cat"}, Stdin: strings.NewReader("1 2\n")})
+	if res.Status != OK || string(res.Stdout) != "1 2\n" {
+		t.Errorf("got %s %q", res.Status, res.Stdout)
+	}
+}
+
+func TestRunDoesNotInheritEnvironment(t *testing.T) {
+	t.Setenv("SANDBOX_TEST_SECRET", "hunter2")
+	res := run(t, Cmd{Args: []string{"env"}, Env: []string{"A=1"}})
+	if strings.Contains(string(res.Stdout), "hunter2") {
+		t.Fatalf("environment leaked:\n%s", res.Stdout)
+	}
+	res = run(t, Cmd{Args: []string{"env"}})
+	if strings.Contains(string(res.Stdout), "hunter2") {
+		t.Fatalf("nil Env leaked the environment:\n%s", res.Stdout)
+	}
+}
+
+// workDir returns a directory that other users can reach, unlike the 0700
+// tree of t.TempDir.
+func workDir(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "sandbox-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	if err := os.Chmod(dir, 0o711); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// isolation returns the isolation of running as nobody, skipping the test
+// where that is impossible.
+func isolation(t *testing.T) *Isolation {
+	t.Helper()
+	if os.Getuid() != 0 {
+		t.Skip("isolation needs root")
+	}
+	iso, err := LookupUser("nobody")
+	if err != nil {
+		t.Skip(err)
+	}
+	return iso
+}
+
+func TestIsolation(t *testing.T) {
+	iso := isolation(t)
+	secret := filepath.Join(workDir(t), "secret")
+	if err := os.WriteFile(secret, []byte("MONGO_URI=x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	res := run(t, Cmd{Args: []string{"id", "-u"}, Isolation: iso})
+	if got := strings.TrimSpace(string(res.Stdout)); got != strconv.Itoa(int(iso.UID)) {
+		t.Errorf("ran as uid %s, want %d", got, iso.UID)
+	}
+
+	res = run(t, Cmd{Args: []string{"cat", secret}, Isolation: iso})
+	if res.Status != RuntimeError || len(res.Stdout) > 0 {
+		t.Errorf("read a file of ours: %s %q", res.Status, res.Stdout)
+	}
+
+	// a fresh network namespace has nothing but lo
+	res = run(t, Cmd{Args: []string{"cat", "/proc/self/net/dev"}, Isolation: iso})
+	for _, line := range strings.Split(string(res.Stdout), "\n")[2:] {
+		if name, _, ok := strings.Cut(strings.TrimSpace(line), ":"); ok && name != "lo" {
+			t.Errorf("interface %s visible", name)
+		}
+	}
+}
+
+func TestIsolationLimitsProcesses(t *testing.T) {
+	iso := isolation(t)
+	res := run(t, Cmd{
+		Args:      []string{"sh", "-c", "for i in $(seq 100); do sleep 5 & done; wait"},
+		Isolation: iso,
+		Limits:    Limits{WallTime: 10 * time.Second, Processes: 10},
+	})
+	if res.Status != RuntimeError || !strings.Contains(string(res.Stderr), "fork") {
+		t.Errorf("fork bomb: %s %q", res.Status, res.Stderr)
+	}
+}
+
+func TestLookupUserRejectsRoot(t *testing.T) {
+	if _, err := LookupUser("root"); err == nil {
+		t.Error("root accepted as sandbox user")
+	}
+}
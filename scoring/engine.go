@@ -0,0 +1,92 @@
+package scoring
+
+import (
+	"context"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// LevelWindow is how many recent solves define a user's level.
+const LevelWindow = 10
+
+// Engine awards points for accepted submissions.
+type Engine struct {
+	Client   *mongo.Client
+	Subrepo  *repository.SubmissionRepository
+	Probrepo *repository.ProblemRepository
+	Userrepo *repository.UserRepository
+	Formula  Formula
+}
+
+// NewEngine creates an engine. Awarding uses multi-document transactions, so
+// client must be connected to a replica set or sharded cluster.
+func NewEngine(client *mongo.Client, sr *repository.SubmissionRepository, pr *repository.ProblemRepository, ur *repository.UserRepository, formula Formula) *Engine {
+	return &Engine{
+		Client:   client,
+		Subrepo:  sr,
+		Probrepo: pr,
+		Userrepo: ur,
+		Formula:  formula,
+	}
+}
+
+// Accept marks submission as an accepted solve of problem and adds its points
+// to the user's score in a single transaction. A submission without an ID is
+// inserted. VerifiedAt, which dates the solve, defaults to now.
+// repository.ErrDuplicate is returned if the user already solved the problem
+// or the submission was already recorded.
+func (e *Engine) Accept(ctx context.Context, submission *models.Submission, problem models.Problem) error {
+	session, err := e.Client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (any, error) {
+		// serialises concurrent solves of the problem, so only one of them
+		// is awarded the first solve
+		if err := e.Probrepo.TouchSolves(sc, problem.ID); err != nil {
+			return nil, err
+		}
+		firstSolve, err := e.Subrepo.IsFirstSolve(sc, submission.ProblemID)
+		if err != nil {
+			return nil, err
+		}
+		level, err := e.Subrepo.Level(sc, submission.UserID, LevelWindow)
+		if err != nil {
+			return nil, err
+		}
+
+		submission.Status = models.SubmissionAccepted
+		submission.Error = ""
+		submission.Difficulty = problem.Difficulty
+		submission.Points = e.Formula.Points(problem.Difficulty, level, firstSolve)
+		submission.UpdatedAt = time.Now()
+		if submission.VerifiedAt.IsZero() {
+			submission.VerifiedAt = submission.UpdatedAt
+		}
+
+		if submission.ID.IsZero() {
+			if submission.CreatedAt.IsZero() {
+				submission.CreatedAt = submission.VerifiedAt
+			}
+			err = e.Subrepo.Create(sc, submission)
+		} else {
+			err = e.Subrepo.MarkAccepted(sc, submission)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		userID, err := primitive.ObjectIDFromHex(submission.UserID)
+		if err != nil {
+			return nil, err
+		}
+		return nil, e.Userrepo.AddScore(sc, userID, submission.Points)
+	})
+	return err
+}
@@ -0,0 +1,80 @@
+package scoring
+
+import (
+	"os"
+	"strconv"
+)
+
+// Formula turns a solved problem into points.
+type Formula struct {
+	// Base is awarded for every solve, including unrated problems.
+	Base int64 `json:"base"`
+	// PerHundred is added for every 100 rating points above MinRating.
+	PerHundred int64 `json:"per_hundred"`
+	// MinRating is the easiest Codeforces rating; problems at or below it
+	// are worth Base.
+	MinRating int `json:"min_rating"`
+	// FirstSolveBonus goes to the first club member to solve a problem.
+	FirstSolveBonus int64 `json:"first_solve_bonus"`
+	// BelowLevelMargin is how far below a user's level a problem may be
+	// before BelowLevelFactor is applied to its points.
+	BelowLevelMargin int `json:"below_level_margin"`
+	// BelowLevelFactor scales the points of problems well below the user's
+	// level, e.g. 0.5 halves them.
+	BelowLevelFactor float64 `json:"below_level_factor"`
+}
+
+// DefaultFormula gives 10 points for an 800 problem, 5 more per 100 rating,
+// 5 for solving first and half points for problems 400 below one's level.
+func DefaultFormula() Formula {
+	return Formula{
+		Base:             10,
+		PerHundred:       5,
+		MinRating:        800,
+		FirstSolveBonus:  5,
+		BelowLevelMargin: 400,
+		BelowLevelFactor: 0.5,
+	}
+}
+
+// FormulaFromEnv starts from DefaultFormula and overrides the fields set in
+// SCORE_BASE, SCORE_PER_HUNDRED, SCORE_MIN_RATING, SCORE_FIRST_SOLVE_BONUS,
+// SCORE_BELOW_LEVEL_MARGIN and SCORE_BELOW_LEVEL_FACTOR.
+func FormulaFromEnv() Formula {
+	f := DefaultFormula()
+	if v, err := strconv.ParseInt(os.Getenv("SCORE_BASE"), 10, 64); err == nil {
+		f.Base = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("SCORE_PER_HUNDRED"), 10, 64); err == nil {
+		f.PerHundred = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("SCORE_MIN_RATING")); err == nil {
+		f.MinRating = v
+	}
+	if v, err := strconv.ParseInt(os.Getenv("SCORE_FIRST_SOLVE_BONUS"), 10, 64); err == nil {
+		f.FirstSolveBonus = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("SCORE_BELOW_LEVEL_MARGIN")); err == nil {
+		f.BelowLevelMargin = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("SCORE_BELOW_LEVEL_FACTOR"), 64); err == nil {
+		f.BelowLevelFactor = v
+	}
+	return f
+}
+
+// Points returns the points for solving a problem of the given difficulty by
+// a user whose level is userLevel. Zero difficulty or level means unknown.
+func (f Formula) Points(difficulty int, userLevel int, firstSolve bool) int64 {
+	points := f.Base
+	if difficulty > f.MinRating {
+		points += int64(difficulty-f.MinRating) / 100 * f.PerHundred
+	}
+	if difficulty > 0 && userLevel > 0 && difficulty < userLevel-f.BelowLevelMargin {
+		points = int64(float64(points) * f.BelowLevelFactor)
+	}
+	if firstSolve {
+		points += f.FirstSolveBonus
+	}
+	return points
+}
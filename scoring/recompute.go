@@ -0,0 +1,69 @@
+package scoring
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Recompute rebuilds the points of every accepted submission and every
+// user's score by replaying the submission history in the order solves were
+// verified. It is not transactional; run it while the workers are stopped.
+func (e *Engine) Recompute(ctx context.Context) (int, error) {
+	submissions, err := e.Subrepo.AcceptedHistory(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	difficulties := make(map[string]int)
+	solved := make(map[string]bool)
+	recent := make(map[string][]int)
+	scores := make(map[primitive.ObjectID]int64)
+
+	for _, s := range submissions {
+		difficulty, ok := difficulties[s.ProblemID]
+		if !ok {
+			// problems may have been re-rated or deleted since the solve
+			difficulty = s.Difficulty
+			if problem, err := e.Probrepo.GetByID(ctx, s.ProblemID); err == nil {
+				difficulty = problem.Difficulty
+			}
+			difficulties[s.ProblemID] = difficulty
+		}
+
+		level := average(recent[s.UserID])
+		points := e.Formula.Points(difficulty, level, !solved[s.ProblemID])
+		solved[s.ProblemID] = true
+		if difficulty > 0 {
+			recent[s.UserID] = append(recent[s.UserID], difficulty)
+			if len(recent[s.UserID]) > LevelWindow {
+				recent[s.UserID] = recent[s.UserID][1:]
+			}
+		}
+
+		if points != s.Points || difficulty != s.Difficulty {
+			if err := e.Subrepo.SetPoints(ctx, s.ID, difficulty, points); err != nil {
+				return 0, err
+			}
+		}
+		if userID, err := primitive.ObjectIDFromHex(s.UserID); err == nil {
+			scores[userID] += points
+		}
+	}
+
+	if err := e.Userrepo.SetScores(ctx, scores); err != nil {
+		return 0, err
+	}
+	return len(submissions), nil
+}
+
+func average(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / len(values)
+}
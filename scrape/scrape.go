@@ -0,0 +1,94 @@
+// Package scrape holds helpers for reading judges that only publish HTML.
+package scrape
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ErrNotFound is returned by Fetch for pages that don't exist.
+var ErrNotFound = errors.New("page not found")
+
+// Fetch downloads and parses an HTML page.
+func Fetch(ctx context.Context, client *http.Client, url string) (*html.Node, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case res.StatusCode != http.StatusOK:
+		io.Copy(io.Discard, res.Body)
+		return nil, fmt.Errorf("GET %s: HTTP %d", url, res.StatusCode)
+	}
+	return html.Parse(res.Body)
+}
+
+// Walk calls fn for n and every node below it in document order.
+func Walk(n *html.Node, fn func(*html.Node)) {
+	fn(n)
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		Walk(c, fn)
+	}
+}
+
+// Text returns the whitespace-normalized text content of n.
+func Text(n *html.Node) string {
+	var sb strings.Builder
+	Walk(n, func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteByte(' ')
+		}
+	})
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// Attr returns the value of attribute key of n.
+func Attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// HasClass reports whether n's class attribute contains class.
+func HasClass(n *html.Node, class string) bool {
+	for _, c := range strings.Fields(Attr(n, "class")) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// IsElement reports whether n is an element with the given tag name.
+func IsElement(n *html.Node, tag string) bool {
+	return n.Type == html.ElementNode && n.Data == tag
+}
+
+// First returns the first element with the given tag below n.
+func First(n *html.Node, tag string) *html.Node {
+	var found *html.Node
+	Walk(n, func(c *html.Node) {
+		if found == nil && c != n && IsElement(c, tag) {
+			found = c
+		}
+	})
+	return found
+}
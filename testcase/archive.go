@@ -0,0 +1,164 @@
+// Package testcase reads test cases out of uploaded archives.
+package testcase
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MaxFileSize bounds a single decompressed test file.
+const MaxFileSize = 64 << 20
+
+// MaxTotalSize bounds the decompressed size of all tests of an archive.
+const MaxTotalSize = 512 << 20
+
+// MaxEntries bounds the number of files in an archive.
+const MaxEntries = 10000
+
+// ErrEmptyArchive is returned when an archive holds no complete test.
+var ErrEmptyArchive = errors.New("archive contains no tests")
+
+// Pair is one test read from an archive.
+type Pair struct {
+	Name   string
+	Input  []byte
+	Output []byte
+}
+
+// outputExts are accepted extensions for the expected output of "<name>.in".
+var outputExts = []string{".out", ".ans"}
+
+// ReadZip returns the tests of a zip archive in natural name order. A test
+// is a "<name>.in" file next to a "<name>.out" or "<name>.ans" file;
+// directories are flattened and other files are ignored.
+func ReadZip(r io.ReaderAt, size int64) ([]Pair, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(archive.File) > MaxEntries {
+		return nil, fmt.Errorf("archive has more than %d files", MaxEntries)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(f.Name)
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("%s appears twice in the archive", name)
+		}
+		files[name] = f
+	}
+
+	var pairs []Pair
+	// directories are flattened, so names must be unique without them
+	seen := make(map[string]string)
+	var budget int64 = MaxTotalSize
+	for name, in := range files {
+		if !strings.HasSuffix(name, ".in") {
+			continue
+		}
+		base := strings.TrimSuffix(name, ".in")
+		var out *zip.File
+		for _, ext := range outputExts {
+			if f, ok := files[base+ext]; ok {
+				out = f
+				break
+			}
+		}
+		if out == nil {
+			return nil, fmt.Errorf("%s has no matching .out or .ans file", name)
+		}
+		testName := path.Base(base)
+		if other, ok := seen[testName]; ok {
+			return nil, fmt.Errorf("%s and %s both name test %q", other, name, testName)
+		}
+		seen[testName] = name
+
+		input, err := readFile(in, &budget)
+		if err != nil {
+			return nil, err
+		}
+		output, err := readFile(out, &budget)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, Pair{Name: testName, Input: input, Output: output})
+	}
+	if len(pairs) == 0 {
+		return nil, ErrEmptyArchive
+	}
+	sort.Slice(pairs, func(i, j int) bool { return naturalLess(pairs[i].Name, pairs[j].Name) })
+	return pairs, nil
+}
+
+// errTooLarge is returned once the tests of an archive exceed MaxTotalSize.
+var errTooLarge = fmt.Errorf("archive decompresses to more than %d bytes", MaxTotalSize)
+
+// readFile decompresses f, taking its size off budget.
+func readFile(f *zip.File, budget *int64) ([]byte, error) {
+	if f.UncompressedSize64 > MaxFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", f.Name, MaxFileSize)
+	}
+	if f.UncompressedSize64 > uint64(*budget) {
+		return nil, errTooLarge
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	// the header size can lie, so cap the read as well
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(rc, MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name, err)
+	}
+	if n > MaxFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", f.Name, MaxFileSize)
+	}
+	if *budget -= n; *budget < 0 {
+		return nil, errTooLarge
+	}
+	return buf.Bytes(), nil
+}
+
+// naturalLess orders names so that "2" sorts before "10".
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := digitPrefix(a), digitPrefix(b)
+		if da != "" && db != "" {
+			na, _ := strconv.ParseUint(da, 10, 64)
+			nb, _ := strconv.ParseUint(db, 10, 64)
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
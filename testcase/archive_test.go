@@ -0,0 +1,83 @@
+package testcase
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// archive zips files, given as alternating names and contents.
+func archive(t *testing.T, files ...string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(files); i += 2 {
+		f, err := w.Create(files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(files[i+1]))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestReadZip(t *testing.T) {
+	r := archive(t,
+		"tests/10.in", "10\n", "tests/10.out", "100\n",
+		"tests/2.in", "2\n", "tests/2.ans", "4\n",
+		"statement.pdf", "ignored",
+	)
+	pairs, err := ReadZip(r, r.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 {
+		t.Fatalf("got %d tests, want 2", len(pairs))
+	}
+	if pairs[0].Name != "2" || string(pairs[0].Output) != "4\n" {
+		t.Errorf("first test = %q with output %q, want 2 with 4", pairs[0].Name, pairs[0].Output)
+	}
+	if pairs[1].Name != "10" || string(pairs[1].Input) != "10\n" {
+		t.Errorf("second test = %q with input %q, want 10 with 10", pairs[1].Name, pairs[1].Input)
+	}
+}
+
+func TestReadZipRejects(t *testing.T) {
+	tests := map[string][]string{
+		"missing output":       {"1.in", "1"},
+		"duplicate names":      {"a/1.in", "1", "a/1.out", "1", "b/1.in", "2", "b/1.out", "2"},
+		"same file twice":      {"1.in", "1", "./1.in", "2", "1.out", "1"},
+		"only other files":     {"readme.txt", "hi"},
+		"output without input": {"1.out", "1"},
+	}
+	for name, files := range tests {
+		r := archive(t, files...)
+		if _, err := ReadZip(r, r.Size()); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestReadZipEntryLimit(t *testing.T) {
+	files := make([]string, 0, 2*(MaxEntries+1))
+	for i := 0; i <= MaxEntries; i++ {
+		files = append(files, strconv.Itoa(i)+".txt", "")
+	}
+	r := archive(t, files...)
+	if _, err := ReadZip(r, r.Size()); err == nil {
+		t.Error("accepted an archive with too many files")
+	}
+}
+
+func TestReadZipFileLimit(t *testing.T) {
+	r := archive(t, "1.in", string(make([]byte, MaxFileSize+1)), "1.out", "")
+	_, err := ReadZip(r, r.Size())
+	if err == nil || errors.Is(err, ErrEmptyArchive) {
+		t.Errorf("got %v, want a size error", err)
+	}
+}
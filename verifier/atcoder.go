@@ -0,0 +1,170 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/scrape"
+	"golang.org/x/net/html"
+)
+
+// DefaultAtCoderURL is the public AtCoder site.
+const DefaultAtCoderURL = "https://atcoder.jp"
+
+// AtCoder verifies submissions by reading the public submission page, as
+// AtCoder has no official API.
+//
+// Problems use the contest id (e.g. "abc300") as ContestID and either the
+// task letter ("A") or the full task id ("abc300_a") as Index.
+type AtCoder struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewAtCoder creates a verifier reading pages from baseURL, or
+// DefaultAtCoderURL when empty.
+func NewAtCoder(baseURL string, timeout time.Duration) *AtCoder {
+	if baseURL == "" {
+		baseURL = DefaultAtCoderURL
+	}
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &AtCoder{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (v *AtCoder) Handle(user models.User) (string, error) {
+	if user.AtCoderUsername == "" || !user.AtCoderVerified {
+		return "", fmt.Errorf("%w: atcoder handle %q", ErrUnverifiedHandle, user.AtCoderUsername)
+	}
+	return user.AtCoderUsername, nil
+}
+
+// NewChallenge asks the owner of handle to set the affiliation on their
+// AtCoder profile to a token, the only method AtCoder supports.
+func (v *AtCoder) NewChallenge(handle string, method string) (*models.HandleChallenge, error) {
+	if method != "" && method != models.ChallengeAffiliation {
+		return nil, fmt.Errorf("unknown challenge method %q", method)
+	}
+	token, err := challengeToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &models.HandleChallenge{
+		Method:    models.ChallengeAffiliation,
+		Handle:    handle,
+		Token:     token,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ChallengeTTL),
+	}, nil
+}
+
+// CheckChallenge reads the user's profile page for the token.
+func (v *AtCoder) CheckChallenge(ctx context.Context, challenge models.HandleChallenge) (string, error) {
+	if time.Now().After(challenge.ExpiresAt) {
+		return "", fmt.Errorf("%w: challenge expired", ErrChallengeFailed)
+	}
+	pageURL := fmt.Sprintf("%s/users/%s?lang=en", v.BaseURL, url.PathEscape(challenge.Handle))
+	doc, err := scrape.Fetch(ctx, v.HTTPClient, pageURL)
+	if errors.Is(err, scrape.ErrNotFound) {
+		return "", fmt.Errorf("%w: no AtCoder user %s", ErrChallengeFailed, challenge.Handle)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if affiliation := atcoderTable(doc)["Affiliation"].text; affiliation != challenge.Token {
+		return "", fmt.Errorf("%w: affiliation is not %q", ErrChallengeFailed, challenge.Token)
+	}
+	handle := challenge.Handle
+	scrape.Walk(doc, func(n *html.Node) {
+		if scrape.IsElement(n, "a") && scrape.HasClass(n, "username") && strings.EqualFold(scrape.Text(n), handle) {
+			handle = scrape.Text(n)
+		}
+	})
+	return handle, nil
+}
+
+// taskID returns the AtCoder task id of problem.
+func (v *AtCoder) taskID(problem models.Problem) string {
+	if strings.Contains(problem.Index, "_") {
+		return strings.ToLower(problem.Index)
+	}
+	return strings.ToLower(problem.ContestID + "_" + problem.Index)
+}
+
+func (v *AtCoder) Verify(ctx context.Context, problem models.Problem, submissionRef string, handle string) error {
+	if _, err := strconv.ParseUint(submissionRef, 10, 64); err != nil {
+		return fmt.Errorf("%w: invalid submission id %q", ErrNotAccepted, submissionRef)
+	}
+	pageURL := fmt.Sprintf("%s/contests/%s/submissions/%s?lang=en", v.BaseURL, url.PathEscape(problem.ContestID), submissionRef)
+
+	doc, err := scrape.Fetch(ctx, v.HTTPClient, pageURL)
+	if errors.Is(err, scrape.ErrNotFound) {
+		return fmt.Errorf("%w: submission %s not found in contest %s", ErrNotAccepted, submissionRef, problem.ContestID)
+	}
+	if err != nil {
+		return err
+	}
+
+	info := atcoderTable(doc)
+	if task := path.Base(info["Task"].href); !strings.EqualFold(task, v.taskID(problem)) {
+		return fmt.Errorf("%w: submission %s is for task %s", ErrNotAccepted, submissionRef, task)
+	}
+	if user := info["User"].text; !strings.EqualFold(user, handle) {
+		return fmt.Errorf("%w: submission %s was not made by %s", ErrNotAccepted, submissionRef, handle)
+	}
+
+	switch status := info["Status"].text; {
+	case status == "AC":
+		return nil
+	case status == "" || status == "WJ" || status == "WR" || strings.Contains(status, "/"):
+		// waiting for or in the middle of judging; the queue will retry
+		return fmt.Errorf("atcoder submission %s is still being judged", submissionRef)
+	default:
+		return fmt.Errorf("%w: submission %s has status %s", ErrNotAccepted, submissionRef, status)
+	}
+}
+
+type atcoderCell struct {
+	text string
+	href string
+}
+
+// atcoderTable reads tables whose rows are a <th> label followed by a <td>
+// value, like "Submission Info" and the profile. The first row with a label
+// wins.
+func atcoderTable(doc *html.Node) map[string]atcoderCell {
+	info := make(map[string]atcoderCell)
+	scrape.Walk(doc, func(n *html.Node) {
+		if !scrape.IsElement(n, "tr") {
+			return
+		}
+		th, td := scrape.First(n, "th"), scrape.First(n, "td")
+		if th == nil || td == nil {
+			return
+		}
+		cell := atcoderCell{text: scrape.Text(td)}
+		if a := scrape.First(td, "a"); a != nil {
+			cell.href = scrape.Attr(a, "href")
+			cell.text = scrape.Text(a)
+		}
+		label := scrape.Text(th)
+		if _, seen := info[label]; !seen {
+			info[label] = cell
+		}
+	})
+	return info
+}
@@ -0,0 +1,137 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+// atcoderServer serves the recorded pages in testdata/atcoder: submission
+// 41234567 of contest abc300 with its status replaced by status, and the
+// profile of Alice.
+func atcoderServer(t *testing.T, status string) *AtCoder {
+	t.Helper()
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join("testdata", "atcoder", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	submission := strings.Replace(read("submission.html"), ">AC<", ">"+status+"<", 1)
+	profile := read("user.html")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/contests/abc300/submissions/41234567":
+			w.Write([]byte(submission))
+		case "/users/alice", "/users/Alice":
+			w.Write([]byte(profile))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewAtCoder(srv.URL, 0)
+}
+
+// errRetry stands for any error other than a rejection in table tests.
+var errRetry = errors.New("retry")
+
+func TestAtCoderVerify(t *testing.T) {
+	problem := models.Problem{Source: "atcoder", ContestID: "abc300", Index: "A"}
+	tests := []struct {
+		name    string
+		status  string
+		problem models.Problem
+		ref     string
+		handle  string
+		// want is nil for success, ErrNotAccepted for rejections and
+		// errRetry for errors that leave the submission queued
+		want error
+	}{
+		{name: "accepted", status: "AC", problem: problem, ref: "41234567", handle: "alice"},
+		{name: "full task id", status: "AC", problem: models.Problem{ContestID: "abc300", Index: "abc300_a"}, ref: "41234567", handle: "Alice"},
+		{name: "wrong answer", status: "WA", problem: problem, ref: "41234567", handle: "Alice", want: ErrNotAccepted},
+		{name: "waiting for judge", status: "WJ", problem: problem, ref: "41234567", handle: "Alice", want: errRetry},
+		{name: "judging", status: "3/12", problem: problem, ref: "41234567", handle: "Alice", want: errRetry},
+		{name: "other user", status: "AC", problem: problem, ref: "41234567", handle: "Bob", want: ErrNotAccepted},
+		{name: "other task", status: "AC", problem: models.Problem{ContestID: "abc300", Index: "B"}, ref: "41234567", handle: "Alice", want: ErrNotAccepted},
+		{name: "unknown submission", status: "AC", problem: problem, ref: "41234568", handle: "Alice", want: ErrNotAccepted},
+		{name: "path in reference", status: "AC", problem: problem, ref: "../../users/Alice", handle: "Alice", want: ErrNotAccepted},
+		{name: "empty reference", status: "AC", problem: problem, ref: "", handle: "Alice", want: ErrNotAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := atcoderServer(t, tt.status)
+			err := v.Verify(context.Background(), tt.problem, tt.ref, tt.handle)
+			switch tt.want {
+			case nil:
+				if err != nil {
+					t.Fatalf("got %v, want nil", err)
+				}
+			case errRetry:
+				if err == nil || errors.Is(err, ErrNotAccepted) {
+					t.Fatalf("got %v, want a retryable error", err)
+				}
+			default:
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestAtCoderHandleNeedsVerification(t *testing.T) {
+	v := NewAtCoder("", 0)
+	if _, err := v.Handle(models.User{AtCoderUsername: "Alice"}); !errors.Is(err, ErrUnverifiedHandle) {
+		t.Errorf("unverified handle: got %v", err)
+	}
+	if handle, err := v.Handle(models.User{AtCoderUsername: "Alice", AtCoderVerified: true}); err != nil || handle != "Alice" {
+		t.Errorf("verified handle: got %q, %v", handle, err)
+	}
+}
+
+func TestAtCoderChallenge(t *testing.T) {
+	v := atcoderServer(t, "AC")
+	challenge, err := v.NewChallenge("alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge.Method != models.ChallengeAffiliation || !strings.HasPrefix(challenge.Token, "aastu-cpc-") {
+		t.Fatalf("unexpected challenge %+v", challenge)
+	}
+	if _, err := v.NewChallenge("alice", models.ChallengeCompileError); err == nil {
+		t.Error("accepted a Codeforces challenge method")
+	}
+
+	// the recorded profile has this affiliation
+	challenge.Token = "aastu-cpc-0123456789ab"
+	handle, err := v.CheckChallenge(context.Background(), *challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if handle != "Alice" {
+		t.Errorf("handle = %q, want AtCoder's spelling Alice", handle)
+	}
+
+	failing := map[string]models.HandleChallenge{
+		"other token":  {Handle: "Alice", Token: "aastu-cpc-ffffffffffff", ExpiresAt: time.Now().Add(time.Minute)},
+		"unknown user": {Handle: "Mallory", Token: "aastu-cpc-0123456789ab", ExpiresAt: time.Now().Add(time.Minute)},
+		"expired":      {Handle: "Alice", Token: "aastu-cpc-0123456789ab", ExpiresAt: time.Now().Add(-time.Minute)},
+	}
+	for name, challenge := range failing {
+		if _, err := v.CheckChallenge(context.Background(), challenge); !errors.Is(err, ErrChallengeFailed) {
+			t.Errorf("%s: got %v, want ErrChallengeFailed", name, err)
+		}
+	}
+}
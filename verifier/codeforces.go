@@ -0,0 +1,74 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/AbenezerWork/AASTU-CPC/codeforces"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+// Codeforces verifies submissions through the Codeforces API.
+type Codeforces struct {
+	Client *codeforces.Client
+	Policy ParticipantPolicy
+}
+
+// NewCodeforces creates a Codeforces verifier using client and the default
+// participant policy.
+func NewCodeforces(client *codeforces.Client) *Codeforces {
+	return &Codeforces{
+		Client: client,
+		Policy: DefaultParticipantPolicy(),
+	}
+}
+
+func (v *Codeforces) Handle(user models.User) (string, error) {
+	if user.CodeforcesUsername == "" || !user.CodeforcesVerified {
+		return "", fmt.Errorf("%w: codeforces handle %q", ErrUnverifiedHandle, user.CodeforcesUsername)
+	}
+	return user.CodeforcesUsername, nil
+}
+
+func (v *Codeforces) Verify(ctx context.Context, problem models.Problem, submissionRef string, handle string) error {
+	contestID, err := strconv.Atoi(problem.ContestID)
+	if err != nil {
+		return fmt.Errorf("problem has invalid contest id %q", problem.ContestID)
+	}
+	submissionID, err := strconv.ParseInt(submissionRef, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid submission id %q", ErrNotAccepted, submissionRef)
+	}
+
+	// a submission made after the last cached response would not be in it
+	submissions, err := v.Client.ContestStatus(codeforces.Fresh(ctx), contestID, handle, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	for _, submission := range submissions {
+		if submission.ID != submissionID {
+			continue
+		}
+		if submission.ContestID != contestID || submission.Problem.Index != problem.Index {
+			return fmt.Errorf("%w: submission %d is for problem %d%s", ErrNotAccepted, submissionID, submission.ContestID, submission.Problem.Index)
+		}
+		if !authoredBy(submission.Author, handle) {
+			return fmt.Errorf("%w: submission %d was not made by %s", ErrNotAccepted, submissionID, handle)
+		}
+		if err := v.Policy.Check(submission.Author); err != nil {
+			return err
+		}
+		switch submission.Verdict {
+		case "OK":
+			return nil
+		case "", "TESTING", "SUBMITTED":
+			// not judged yet; the queue will retry
+			return fmt.Errorf("codeforces submission %d is still being judged", submissionID)
+		default:
+			return fmt.Errorf("%w: submission %d has verdict %s", ErrNotAccepted, submissionID, submission.Verdict)
+		}
+	}
+	return fmt.Errorf("%w: submission %d not found for %s in contest %d", ErrNotAccepted, submissionID, handle, contestID)
+}
@@ -0,0 +1,133 @@
+package verifier
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/codeforces"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+// ErrChallengeFailed is returned when a handle challenge was not completed.
+var ErrChallengeFailed = errors.New("handle challenge not completed")
+
+// ChallengeTTL is how long a user has to complete a handle challenge.
+var ChallengeTTL = 10 * time.Minute
+
+// ChallengeProblems are the problems users are asked to submit a
+// compilation error to. Each challenge picks one at random, so a
+// compilation error made for another purpose, or for someone else's
+// challenge, is unlikely to pass.
+var ChallengeProblems = []models.Problem{
+	{ContestID: "1", Index: "A"}, {ContestID: "4", Index: "A"}, {ContestID: "41", Index: "A"},
+	{ContestID: "50", Index: "A"}, {ContestID: "71", Index: "A"}, {ContestID: "96", Index: "A"},
+	{ContestID: "110", Index: "A"}, {ContestID: "112", Index: "A"}, {ContestID: "158", Index: "A"},
+	{ContestID: "231", Index: "A"}, {ContestID: "236", Index: "A"}, {ContestID: "263", Index: "A"},
+	{ContestID: "266", Index: "A"}, {ContestID: "281", Index: "A"}, {ContestID: "282", Index: "A"},
+	{ContestID: "339", Index: "A"}, {ContestID: "546", Index: "A"}, {ContestID: "617", Index: "A"},
+	{ContestID: "791", Index: "A"}, {ContestID: "977", Index: "A"},
+}
+
+// challengeToken returns a random token for a user to put on their profile.
+func challengeToken() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "aastu-cpc-" + hex.EncodeToString(buf), nil
+}
+
+// NewChallenge creates a challenge proving ownership of handle by method.
+func (v *Codeforces) NewChallenge(handle string, method string) (*models.HandleChallenge, error) {
+	now := time.Now()
+	challenge := &models.HandleChallenge{
+		Method:    method,
+		Handle:    handle,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ChallengeTTL),
+	}
+	switch method {
+	case models.ChallengeCompileError:
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(ChallengeProblems))))
+		if err != nil {
+			return nil, err
+		}
+		problem := ChallengeProblems[n.Int64()]
+		challenge.ContestID = problem.ContestID
+		challenge.Index = problem.Index
+	case models.ChallengeFirstName:
+		token, err := challengeToken()
+		if err != nil {
+			return nil, err
+		}
+		challenge.Token = token
+	default:
+		return nil, fmt.Errorf("unknown challenge method %q", method)
+	}
+	return challenge, nil
+}
+
+// CheckChallenge asks Codeforces whether challenge was completed. On success
+// it returns the handle spelled the way Codeforces spells it.
+func (v *Codeforces) CheckChallenge(ctx context.Context, challenge models.HandleChallenge) (string, error) {
+	if time.Now().After(challenge.ExpiresAt) {
+		return "", fmt.Errorf("%w: challenge expired", ErrChallengeFailed)
+	}
+
+	switch challenge.Method {
+	case models.ChallengeCompileError:
+		return v.checkCompileError(ctx, challenge)
+	case models.ChallengeFirstName:
+		return v.checkFirstName(ctx, challenge)
+	}
+	return "", fmt.Errorf("unknown challenge method %q", challenge.Method)
+}
+
+func (v *Codeforces) checkCompileError(ctx context.Context, challenge models.HandleChallenge) (string, error) {
+	contestID, err := strconv.Atoi(challenge.ContestID)
+	if err != nil {
+		return "", fmt.Errorf("challenge has invalid contest id %q", challenge.ContestID)
+	}
+	// the submission was probably made moments ago, after the result the
+	// cache holds
+	submissions, err := v.Client.UserStatus(codeforces.Fresh(ctx), challenge.Handle, 1, 10)
+	if err != nil {
+		return "", err
+	}
+	for _, submission := range submissions {
+		created := time.Unix(submission.CreationTimeSeconds, 0)
+		if created.Before(challenge.IssuedAt) || created.After(challenge.ExpiresAt) {
+			continue
+		}
+		if submission.ContestID != contestID || submission.Problem.Index != challenge.Index {
+			continue
+		}
+		if submission.Verdict != "COMPILATION_ERROR" {
+			continue
+		}
+		for _, member := range submission.Author.Members {
+			if strings.EqualFold(member.Handle, challenge.Handle) {
+				return member.Handle, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: no compilation error on %s%s since %s", ErrChallengeFailed, challenge.ContestID, challenge.Index, challenge.IssuedAt.Format(time.RFC3339))
+}
+
+func (v *Codeforces) checkFirstName(ctx context.Context, challenge models.HandleChallenge) (string, error) {
+	users, err := v.Client.UserInfo(ctx, challenge.Handle)
+	if err != nil {
+		return "", err
+	}
+	if len(users) != 1 || strings.TrimSpace(users[0].FirstName) != challenge.Token {
+		return "", fmt.Errorf("%w: first name is not %q", ErrChallengeFailed, challenge.Token)
+	}
+	return users[0].Handle, nil
+}
@@ -0,0 +1,74 @@
+package verifier
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/AbenezerWork/AASTU-CPC/codeforces"
+)
+
+// Codeforces participant types, see Party.participantType in the API docs.
+const (
+	ParticipantContestant       = "CONTESTANT"
+	ParticipantPractice         = "PRACTICE"
+	ParticipantVirtual          = "VIRTUAL"
+	ParticipantManager          = "MANAGER"
+	ParticipantOutOfCompetition = "OUT_OF_COMPETITION"
+)
+
+// ParticipantPolicy decides which kinds of Codeforces participation count.
+type ParticipantPolicy struct {
+	// AllowedTypes holds the participant types whose submissions are accepted.
+	AllowedTypes map[string]bool
+	// AllowGhosts accepts ghost participants, i.e. results of contests held
+	// elsewhere and uploaded to Codeforces.
+	AllowGhosts bool
+}
+
+// DefaultParticipantPolicy accepts everything but ghosts and contest managers,
+// who can see the tests.
+func DefaultParticipantPolicy() ParticipantPolicy {
+	return ParticipantPolicy{AllowedTypes: map[string]bool{
+		ParticipantContestant:       true,
+		ParticipantPractice:         true,
+		ParticipantVirtual:          true,
+		ParticipantOutOfCompetition: true,
+	}}
+}
+
+// ParseParticipantTypes parses a comma separated list such as
+// "CONTESTANT,PRACTICE". An empty string yields the default allowed types.
+func ParseParticipantTypes(list string) map[string]bool {
+	if strings.TrimSpace(list) == "" {
+		return DefaultParticipantPolicy().AllowedTypes
+	}
+	types := make(map[string]bool)
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.ToUpper(strings.TrimSpace(t)); t != "" {
+			types[t] = true
+		}
+	}
+	return types
+}
+
+// Check returns an error wrapping ErrNotAccepted if party may not earn credit.
+func (p ParticipantPolicy) Check(party codeforces.Party) error {
+	if party.Ghost && !p.AllowGhosts {
+		return fmt.Errorf("%w: ghost participants are not accepted", ErrNotAccepted)
+	}
+	if !p.AllowedTypes[party.ParticipantType] {
+		return fmt.Errorf("%w: participant type %s is not accepted", ErrNotAccepted, party.ParticipantType)
+	}
+	return nil
+}
+
+// authoredBy reports whether handle is the author of a submission or, for
+// team submissions, one of its members.
+func authoredBy(party codeforces.Party, handle string) bool {
+	for _, member := range party.Members {
+		if strings.EqualFold(member.Handle, handle) {
+			return true
+		}
+	}
+	return false
+}
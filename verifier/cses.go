@@ -0,0 +1,134 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/scrape"
+	"golang.org/x/net/html"
+)
+
+// DefaultCSESURL is the public CSES site.
+const DefaultCSESURL = "https://cses.fi"
+
+// CSES verifies solves of the CSES problem set by reading the user's public
+// results page, which marks every fully solved task. CSES has no API and no
+// public submission pages, so the submission reference is ignored.
+//
+// Problems use ContestID "problemset" and the numeric task id as Index.
+type CSES struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// NewCSES creates a verifier reading pages from baseURL, or DefaultCSESURL
+// when empty.
+func NewCSES(baseURL string, timeout time.Duration) *CSES {
+	if baseURL == "" {
+		baseURL = DefaultCSESURL
+	}
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &CSES{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Handle returns the numeric CSES user id, found in the URL of the user's
+// page on cses.fi.
+func (v *CSES) Handle(user models.User) (string, error) {
+	if user.CSESUserID == "" || !user.CSESVerified {
+		return "", fmt.Errorf("%w: CSES user id %q", ErrUnverifiedHandle, user.CSESUserID)
+	}
+	return user.CSESUserID, nil
+}
+
+// NewChallenge asks the owner of the account with user id handle to set its
+// name to a token, which CSES shows on the user's page.
+func (v *CSES) NewChallenge(handle string, method string) (*models.HandleChallenge, error) {
+	if method != "" && method != models.ChallengeName {
+		return nil, fmt.Errorf("unknown challenge method %q", method)
+	}
+	if _, err := strconv.ParseUint(handle, 10, 64); err != nil {
+		return nil, fmt.Errorf("CSES user id %q is not a number", handle)
+	}
+	token, err := challengeToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &models.HandleChallenge{
+		Method:    models.ChallengeName,
+		Handle:    handle,
+		Token:     token,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ChallengeTTL),
+	}, nil
+}
+
+// CheckChallenge looks for the token on the user's page. The page only
+// shows that user's own data, so the token being there anywhere means the
+// name was changed.
+func (v *CSES) CheckChallenge(ctx context.Context, challenge models.HandleChallenge) (string, error) {
+	if time.Now().After(challenge.ExpiresAt) {
+		return "", fmt.Errorf("%w: challenge expired", ErrChallengeFailed)
+	}
+	pageURL := fmt.Sprintf("%s/user/%s/", v.BaseURL, url.PathEscape(challenge.Handle))
+	doc, err := scrape.Fetch(ctx, v.HTTPClient, pageURL)
+	if errors.Is(err, scrape.ErrNotFound) {
+		return "", fmt.Errorf("%w: no CSES user %s", ErrChallengeFailed, challenge.Handle)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(scrape.Text(doc), challenge.Token) {
+		return "", fmt.Errorf("%w: name is not %q", ErrChallengeFailed, challenge.Token)
+	}
+	return challenge.Handle, nil
+}
+
+// Reference identifies a CSES solve by user and task.
+func (v *CSES) Reference(problem models.Problem, handle string) string {
+	return handle + "/" + problem.Index
+}
+
+func (v *CSES) Verify(ctx context.Context, problem models.Problem, submissionRef string, handle string) error {
+	pageURL := fmt.Sprintf("%s/problemset/user/%s/", v.BaseURL, url.PathEscape(handle))
+	doc, err := scrape.Fetch(ctx, v.HTTPClient, pageURL)
+	if errors.Is(err, scrape.ErrNotFound) {
+		return fmt.Errorf("%w: CSES user %s not found", ErrNotAccepted, handle)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !csesSolved(doc, problem.Index) {
+		return fmt.Errorf("%w: task %s is not solved by CSES user %s", ErrNotAccepted, problem.Index, handle)
+	}
+	return nil
+}
+
+// csesSolved looks for the task's link, which carries the class "full" once
+// the task is solved.
+func csesSolved(doc *html.Node, taskID string) bool {
+	target := "/problemset/task/" + taskID
+	solved := false
+	scrape.Walk(doc, func(n *html.Node) {
+		if solved || !scrape.IsElement(n, "a") {
+			return
+		}
+		if strings.TrimRight(scrape.Attr(n, "href"), "/") == target && scrape.HasClass(n, "full") {
+			solved = true
+		}
+	})
+	return solved
+}
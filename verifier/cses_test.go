@@ -0,0 +1,78 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+const csesUserPage = `<html><head><title>CSES - User aastu-cpc-0123456789ab</title></head><body>
+<div class="content"><h1>User aastu-cpc-0123456789ab</h1>
+<table><tr><td>Submission count:</td><td><a href="/problemset/user/4242/">117</a></td></tr></table></div>
+</body></html>`
+
+const csesResultsPage = `<html><body><div class="content"><table class="task-list">
+<tr><td><a href="/problemset/task/1068/" class="task-score icon full"></a></td><td><a href="/problemset/task/1068">Weird Algorithm</a></td></tr>
+<tr><td><a href="/problemset/task/1083/" class="task-score icon zero"></a></td><td><a href="/problemset/task/1083">Missing Number</a></td></tr>
+</table></div></body></html>`
+
+func csesServer(t *testing.T) *CSES {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/user/4242/":
+			w.Write([]byte(csesUserPage))
+		case "/problemset/user/4242/":
+			w.Write([]byte(csesResultsPage))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewCSES(srv.URL, 0)
+}
+
+func TestCSESVerify(t *testing.T) {
+	v := csesServer(t)
+	if err := v.Verify(context.Background(), models.Problem{Index: "1068"}, "", "4242"); err != nil {
+		t.Errorf("solved task: %v", err)
+	}
+	if err := v.Verify(context.Background(), models.Problem{Index: "1083"}, "", "4242"); !errors.Is(err, ErrNotAccepted) {
+		t.Errorf("unsolved task: got %v", err)
+	}
+	if err := v.Verify(context.Background(), models.Problem{Index: "1068"}, "", "4343"); !errors.Is(err, ErrNotAccepted) {
+		t.Errorf("unknown user: got %v", err)
+	}
+	if _, err := v.Handle(models.User{CSESUserID: "4242"}); !errors.Is(err, ErrUnverifiedHandle) {
+		t.Errorf("unverified user id: got %v", err)
+	}
+}
+
+func TestCSESChallenge(t *testing.T) {
+	v := csesServer(t)
+	if _, err := v.NewChallenge("alice", ""); err == nil {
+		t.Error("accepted a non-numeric user id")
+	}
+	challenge, err := v.NewChallenge("4242", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.CheckChallenge(context.Background(), *challenge); !errors.Is(err, ErrChallengeFailed) {
+		t.Errorf("name not changed: got %v", err)
+	}
+
+	// the user page above carries this name
+	challenge.Token = "aastu-cpc-0123456789ab"
+	if handle, err := v.CheckChallenge(context.Background(), *challenge); err != nil || handle != "4242" {
+		t.Errorf("name changed: got %q, %v", handle, err)
+	}
+	challenge.ExpiresAt = time.Now().Add(-time.Second)
+	if _, err := v.CheckChallenge(context.Background(), *challenge); !errors.Is(err, ErrChallengeFailed) {
+		t.Errorf("expired: got %v", err)
+	}
+}
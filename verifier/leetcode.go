@@ -0,0 +1,205 @@
+package verifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+// DefaultLeetCodeURL is LeetCode's public GraphQL endpoint.
+const DefaultLeetCodeURL = "https://leetcode.com/graphql"
+
+// leetCodeRecentLimit is how many recent accepted submissions are checked;
+// LeetCode returns at most 20 without logging in.
+const leetCodeRecentLimit = 20
+
+const profileQuery = `query userPublicProfile($username: String!) {
+  matchedUser(username: $username) {
+    username
+    profile {
+      aboutMe
+    }
+  }
+}`
+
+const recentAcQuery = `query recentAcSubmissions($username: String!, $limit: Int!) {
+  recentAcSubmissionList(username: $username, limit: $limit) {
+    id
+    title
+    titleSlug
+    timestamp
+  }
+}`
+
+// LeetCode verifies solves through the recent accepted submissions of a
+// user's public profile. Problems are identified by their Slug, e.g. "two-sum".
+//
+// Only recent solves can be seen, so members should validate soon after
+// solving. The submission reference is the numeric submission id, which
+// must be among them.
+type LeetCode struct {
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// NewLeetCode creates a verifier querying endpoint, or DefaultLeetCodeURL
+// when empty.
+func NewLeetCode(endpoint string, timeout time.Duration) *LeetCode {
+	if endpoint == "" {
+		endpoint = DefaultLeetCodeURL
+	}
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &LeetCode{
+		Endpoint:   endpoint,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (v *LeetCode) Handle(user models.User) (string, error) {
+	if user.LeetCodeUsername == "" || !user.LeetCodeVerified {
+		return "", fmt.Errorf("%w: leetcode handle %q", ErrUnverifiedHandle, user.LeetCodeUsername)
+	}
+	return user.LeetCodeUsername, nil
+}
+
+// NewChallenge asks the owner of handle to put a token in the summary
+// ("About me") of their LeetCode profile.
+func (v *LeetCode) NewChallenge(handle string, method string) (*models.HandleChallenge, error) {
+	if method != "" && method != models.ChallengeAboutMe {
+		return nil, fmt.Errorf("unknown challenge method %q", method)
+	}
+	token, err := challengeToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &models.HandleChallenge{
+		Method:    models.ChallengeAboutMe,
+		Handle:    handle,
+		Token:     token,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(ChallengeTTL),
+	}, nil
+}
+
+// CheckChallenge reads the profile summary for the token.
+func (v *LeetCode) CheckChallenge(ctx context.Context, challenge models.HandleChallenge) (string, error) {
+	if time.Now().After(challenge.ExpiresAt) {
+		return "", fmt.Errorf("%w: challenge expired", ErrChallengeFailed)
+	}
+	var data struct {
+		MatchedUser *struct {
+			Username string `json:"username"`
+			Profile  struct {
+				AboutMe string `json:"aboutMe"`
+			} `json:"profile"`
+		} `json:"matchedUser"`
+	}
+	err := v.query(ctx, profileQuery, map[string]any{"username": challenge.Handle}, &data)
+	if errors.Is(err, errLeetCodeNoUser) || err == nil && data.MatchedUser == nil {
+		return "", fmt.Errorf("%w: no LeetCode user %s", ErrChallengeFailed, challenge.Handle)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !strings.Contains(data.MatchedUser.Profile.AboutMe, challenge.Token) {
+		return "", fmt.Errorf("%w: summary does not contain %q", ErrChallengeFailed, challenge.Token)
+	}
+	return data.MatchedUser.Username, nil
+}
+
+type leetCodeSubmission struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	TitleSlug string `json:"titleSlug"`
+	Timestamp string `json:"timestamp"`
+}
+
+func (v *LeetCode) Verify(ctx context.Context, problem models.Problem, submissionRef string, handle string) error {
+	if problem.Slug == "" {
+		return fmt.Errorf("problem %s has no LeetCode slug", problem.ID.Hex())
+	}
+	if _, err := strconv.ParseUint(submissionRef, 10, 64); err != nil {
+		return fmt.Errorf("%w: invalid submission id %q", ErrNotAccepted, submissionRef)
+	}
+
+	var data struct {
+		RecentAcSubmissionList *[]leetCodeSubmission `json:"recentAcSubmissionList"`
+	}
+	err := v.query(ctx, recentAcQuery, map[string]any{"username": handle, "limit": leetCodeRecentLimit}, &data)
+	if errors.Is(err, errLeetCodeNoUser) || err == nil && data.RecentAcSubmissionList == nil {
+		return fmt.Errorf("%w: LeetCode user %s not found", ErrNotAccepted, handle)
+	}
+	if err != nil {
+		return err
+	}
+	for _, s := range *data.RecentAcSubmissionList {
+		if s.TitleSlug == problem.Slug && s.ID == submissionRef {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: submission %s is not a recent accepted submission to %s by %s", ErrNotAccepted, submissionRef, problem.Slug, handle)
+}
+
+// errLeetCodeNoUser is returned by query when LeetCode reports that the
+// user asked about does not exist.
+var errLeetCodeNoUser = errors.New("leetcode user does not exist")
+
+// query runs a GraphQL query and decodes its data into data.
+func (v *LeetCode) query(ctx context.Context, query string, variables map[string]any, data any) error {
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Referer", strings.TrimSuffix(v.Endpoint, "/graphql"))
+
+	res, err := v.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("leetcode graphql: HTTP %d", res.StatusCode)
+	}
+
+	var response struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return fmt.Errorf("leetcode graphql: %w", err)
+	}
+	if len(response.Errors) > 0 {
+		// unknown users come back as an error, not an empty result
+		msg := response.Errors[0].Message
+		if strings.Contains(strings.ToLower(msg), "does not exist") {
+			return fmt.Errorf("%w: %s", errLeetCodeNoUser, msg)
+		}
+		return fmt.Errorf("leetcode graphql: %s", msg)
+	}
+	if len(response.Data) == 0 || string(response.Data) == "null" {
+		return errors.New("leetcode graphql: no data")
+	}
+	if err := json.Unmarshal(response.Data, data); err != nil {
+		return fmt.Errorf("leetcode graphql: %w", err)
+	}
+	return nil
+}
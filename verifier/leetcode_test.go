@@ -0,0 +1,104 @@
+package verifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+// fakeLeetCode answers the GraphQL queries the verifier makes the way
+// leetcode.com does, for one user "alice".
+func fakeLeetCode(t *testing.T) *LeetCode {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if !strings.EqualFold(req.Variables["username"].(string), "alice") {
+			w.Write([]byte(`{"errors":[{"message":"That user does not exist.","locations":[{"line":2,"column":3}],"path":["recentAcSubmissionList"],"extensions":{"handled":true}}],"data":{"recentAcSubmissionList":null}}`))
+			return
+		}
+		switch {
+		case strings.Contains(req.Query, "recentAcSubmissionList"):
+			w.Write([]byte(`{"data":{"recentAcSubmissionList":[
+				{"id":"1234567890","title":"Two Sum","titleSlug":"two-sum","timestamp":"1700000000"},
+				{"id":"1234567000","title":"Add Two Numbers","titleSlug":"add-two-numbers","timestamp":"1699990000"}]}}`))
+		case strings.Contains(req.Query, "matchedUser"):
+			w.Write([]byte(`{"data":{"matchedUser":{"username":"Alice","profile":{"aboutMe":"competitive programmer aastu-cpc-0123456789ab"}}}}`))
+		default:
+			http.Error(w, "unexpected query", http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewLeetCode(srv.URL+"/graphql", 0)
+}
+
+func TestLeetCodeVerify(t *testing.T) {
+	v := fakeLeetCode(t)
+	twoSum := models.Problem{Source: "leetcode", Slug: "two-sum"}
+	if err := v.Verify(context.Background(), twoSum, "1234567890", "alice"); err != nil {
+		t.Errorf("accepted submission: %v", err)
+	}
+	rejected := map[string]struct {
+		problem models.Problem
+		ref     string
+		handle  string
+	}{
+		"empty reference":   {twoSum, "", "alice"},
+		"other submission":  {twoSum, "1234567000", "alice"},
+		"unknown reference": {twoSum, "99", "alice"},
+		"not a number":      {twoSum, "two-sum", "alice"},
+		"unknown user":      {twoSum, "1234567890", "mallory"},
+	}
+	for name, tt := range rejected {
+		if err := v.Verify(context.Background(), tt.problem, tt.ref, tt.handle); !errors.Is(err, ErrNotAccepted) {
+			t.Errorf("%s: got %v, want ErrNotAccepted", name, err)
+		}
+	}
+}
+
+func TestLeetCodeUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	err := NewLeetCode(srv.URL, 0).Verify(context.Background(), models.Problem{Slug: "two-sum"}, "1234567890", "alice")
+	if err == nil || errors.Is(err, ErrNotAccepted) {
+		t.Errorf("got %v, want a retryable error", err)
+	}
+}
+
+func TestLeetCodeChallenge(t *testing.T) {
+	v := fakeLeetCode(t)
+	if _, err := v.Handle(models.User{LeetCodeUsername: "alice"}); !errors.Is(err, ErrUnverifiedHandle) {
+		t.Errorf("unverified handle: got %v", err)
+	}
+
+	challenge, err := v.NewChallenge("alice", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := v.CheckChallenge(context.Background(), *challenge); !errors.Is(err, ErrChallengeFailed) {
+		t.Errorf("token not in summary: got %v", err)
+	}
+	challenge.Token = "aastu-cpc-0123456789ab"
+	if handle, err := v.CheckChallenge(context.Background(), *challenge); err != nil || handle != "Alice" {
+		t.Errorf("token in summary: got %q, %v", handle, err)
+	}
+	challenge.Handle = "mallory"
+	if _, err := v.CheckChallenge(context.Background(), *challenge); !errors.Is(err, ErrChallengeFailed) {
+		t.Errorf("unknown user: got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package verifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+)
+
+// ErrUnsupportedJudge is returned when no verifier is registered for a problem source.
+var ErrUnsupportedJudge = errors.New("unsupported judge")
+
+// ErrUnverifiedHandle is returned when the user has not proven they own their
+// account on the judge.
+var ErrUnverifiedHandle = errors.New("judge handle not verified")
+
+// ErrNotAccepted is returned when the judge was reachable but the submission
+// does not prove that the user solved the problem.
+var ErrNotAccepted = errors.New("submission not accepted")
+
+// Verifier checks a submission made on an external online judge.
+type Verifier interface {
+	// Handle returns the user's account name on this judge, or an error
+	// wrapping ErrUnverifiedHandle if it cannot be trusted yet.
+	Handle(user models.User) (string, error)
+	// Verify returns nil when submissionRef is an accepted solution to problem
+	// made by handle. Rejections wrap ErrNotAccepted; any other error means the
+	// judge could not be asked.
+	Verify(ctx context.Context, problem models.Problem, submissionRef string, handle string) error
+}
+
+// Referencer is implemented by verifiers of judges that don't expose
+// individual submissions. Such judges get a reference derived from the
+// problem and handle instead of one supplied by the user, so duplicate
+// detection still works.
+type Referencer interface {
+	Reference(problem models.Problem, handle string) string
+}
+
+// Challenger is implemented by verifiers that can check a user owns their
+// handle on the judge, by having them change something only the owner can.
+type Challenger interface {
+	// NewChallenge sets a challenge proving ownership of handle by method,
+	// one of the models.Challenge* the judge supports. An empty method
+	// picks the judge's default, if it has one.
+	NewChallenge(handle string, method string) (*models.HandleChallenge, error)
+	// CheckChallenge returns the handle, spelled the way the judge spells
+	// it, once challenge is completed. Incomplete or expired challenges
+	// wrap ErrChallengeFailed; any other error means the judge could not be
+	// asked.
+	CheckChallenge(ctx context.Context, challenge models.HandleChallenge) (string, error)
+}
+
+// Registry maps models.Problem.Source values to verifiers.
+type Registry struct {
+	mu        sync.RWMutex
+	verifiers map[string]Verifier
+}
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{verifiers: make(map[string]Verifier)}
+}
+
+// Register adds or replaces the verifier for source.
+func (r *Registry) Register(source string, v Verifier) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.verifiers[source] = v
+}
+
+// Get returns the verifier for source or an error wrapping ErrUnsupportedJudge.
+func (r *Registry) Get(source string) (Verifier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	v, ok := r.verifiers[source]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedJudge, source)
+	}
+	return v, nil
+}
+
+// Verify looks up the verifier for the problem's source and runs it against
+// the user's handle on that judge.
+func (r *Registry) Verify(ctx context.Context, problem models.Problem, submissionRef string, user models.User) error {
+	v, err := r.Get(problem.Source)
+	if err != nil {
+		return err
+	}
+	handle, err := v.Handle(user)
+	if err != nil {
+		return err
+	}
+	return v.Verify(ctx, problem, submissionRef, handle)
+}
@@ -0,0 +1,257 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/grading"
+	"github.com/AbenezerWork/AASTU-CPC/judge"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/scoring"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// JudgeWorker grades native submissions taken from the judge job queue.
+// Any number of workers, in the server or in separate judge-worker
+// processes, can share the queue.
+type JudgeWorker struct {
+	Jobrepo  *repository.JudgeJobRepository
+	Subrepo  *repository.SubmissionRepository
+	Probrepo *repository.ProblemRepository
+	Judge    *judge.Judge
+	Scorer   *scoring.Engine
+
+	// ID identifies this process in job leases.
+	ID string
+	// Workers is the number of submissions judged concurrently. It is 0
+	// by default, so only processes meant for judging run submissions.
+	Workers int
+	// MaxAttempts is how many times a job is tried when judging itself
+	// fails, e.g. because the database is unreachable.
+	MaxAttempts  int
+	PollInterval time.Duration
+	Backoff      time.Duration
+	// JobTimeout bounds judging one submission on every test.
+	JobTimeout time.Duration
+	// Lease is how long a job stays with a worker that stops renewing it.
+	Lease time.Duration
+}
+
+// NewJudgeWorker creates a worker with default settings.
+func NewJudgeWorker(jr *repository.JudgeJobRepository, sr *repository.SubmissionRepository, pr *repository.ProblemRepository, j *judge.Judge, scorer *scoring.Engine) *JudgeWorker {
+	host, _ := os.Hostname()
+	return &JudgeWorker{
+		Jobrepo:      jr,
+		Subrepo:      sr,
+		Probrepo:     pr,
+		Judge:        j,
+		Scorer:       scorer,
+		ID:           fmt.Sprintf("%s-%d", host, os.Getpid()),
+		Workers:      0,
+		MaxAttempts:  3,
+		PollInterval: time.Second,
+		Backoff:      30 * time.Second,
+		JobTimeout:   4 * time.Minute,
+		Lease:        30 * time.Second,
+	}
+}
+
+// Run judges submissions until ctx is cancelled.
+func (w *JudgeWorker) Run(ctx context.Context) {
+	w.enqueueUnjudged(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.Workers; i++ {
+		wg.Add(1)
+		go func(workerID string) {
+			defer wg.Done()
+			w.loop(ctx, workerID)
+		}(fmt.Sprintf("%s/%d", w.ID, i))
+	}
+	wg.Wait()
+}
+
+// enqueueUnjudged queues native submissions that have no job, such as
+// ones accepted before the job queue existed.
+func (w *JudgeWorker) enqueueUnjudged(ctx context.Context) {
+	ids, err := w.Subrepo.UnjudgedNative(ctx)
+	if err != nil {
+		log.Println("judge: listing unjudged submissions:", err)
+		return
+	}
+	for _, id := range ids {
+		if err := w.Jobrepo.Enqueue(ctx, id); err != nil {
+			log.Printf("judge: enqueueing submission %s: %v", id.Hex(), err)
+		}
+	}
+}
+
+func (w *JudgeWorker) loop(ctx context.Context, workerID string) {
+	for {
+		job, err := w.Jobrepo.Claim(ctx, workerID, w.Lease, w.MaxAttempts)
+		if err == nil {
+			w.process(ctx, workerID, job)
+			continue
+		}
+		if errors.Is(err, repository.ErrAttemptsExhausted) {
+			log.Printf("judge: job %s: %s", job.ID.Hex(), job.Error)
+			if err := w.Subrepo.Finish(ctx, job.SubmissionID, models.SubmissionError, job.Error); err != nil {
+				log.Printf("judge: updating submission %s: %v", job.SubmissionID.Hex(), err)
+			}
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println("judge: claiming job:", err)
+		}
+		if !sleep(ctx, w.PollInterval) {
+			return
+		}
+	}
+}
+
+func (w *JudgeWorker) process(ctx context.Context, workerID string, job *models.JudgeJob) {
+	submission, err := w.Subrepo.GetByID(ctx, job.SubmissionID.Hex())
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if err := w.Jobrepo.Finish(ctx, job.ID, workerID, models.JobFailed, "submission deleted"); err != nil {
+			log.Printf("judge: updating job %s: %v", job.ID.Hex(), err)
+		}
+		return
+	}
+	if err == nil {
+		err = w.Subrepo.StartJudging(ctx, submission.ID, 0)
+	}
+	if err != nil {
+		w.settle(ctx, workerID, job, err)
+		return
+	}
+
+	judgeCtx, cancel := context.WithTimeout(ctx, w.JobTimeout)
+	var lost atomic.Bool
+	go w.heartbeat(judgeCtx, cancel, job, workerID, &lost)
+	problem, result, err := w.judge(judgeCtx, submission)
+	cancel()
+
+	if lost.Load() {
+		// another worker has the job now and will write the results
+		log.Printf("judge: lost lease on job %s", job.ID.Hex())
+		return
+	}
+	if err == nil {
+		// The lease may have run out while judging without the heartbeat
+		// noticing yet. Renewing it first means no other worker can have
+		// judged the submission, or take it over while we write the verdict.
+		err = w.Jobrepo.Extend(ctx, job.ID, workerID, w.Lease)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("judge: lost lease on job %s", job.ID.Hex())
+			return
+		}
+	}
+	if err == nil {
+		err = w.record(ctx, submission, problem, result)
+	}
+	w.settle(ctx, workerID, job, err)
+}
+
+// heartbeat renews the job's lease until ctx ends. If the lease is lost it
+// flags lost and cancels the judging.
+func (w *JudgeWorker) heartbeat(ctx context.Context, cancel context.CancelFunc, job *models.JudgeJob, workerID string, lost *atomic.Bool) {
+	for sleep(ctx, w.Lease/3) {
+		err := w.Jobrepo.Extend(ctx, job.ID, workerID, w.Lease)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			lost.Store(true)
+			cancel()
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("judge: renewing lease on job %s: %v", job.ID.Hex(), err)
+		}
+	}
+}
+
+func (w *JudgeWorker) judge(ctx context.Context, submission *models.Submission) (*models.Problem, *judge.Result, error) {
+	problem, err := w.Probrepo.GetByID(ctx, submission.ProblemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	progress := submissionProgress{ctx: ctx, repo: w.Subrepo, submission: submission}
+	result, err := w.Judge.Judge(ctx, *problem, *submission, progress)
+	return problem, result, err
+}
+
+// settle moves the job, and with it the submission, on after an attempt.
+// The submission is left alone if the job has moved to another worker.
+func (w *JudgeWorker) settle(ctx context.Context, workerID string, job *models.JudgeJob, err error) {
+	var jobErr, subErr error
+	switch {
+	case err == nil:
+		jobErr = w.Jobrepo.Finish(ctx, job.ID, workerID, models.JobDone, "")
+	case errors.Is(err, judge.ErrUnknownLanguage), errors.Is(err, judge.ErrNoTests):
+		if jobErr = w.Jobrepo.Finish(ctx, job.ID, workerID, models.JobDone, err.Error()); jobErr == nil {
+			subErr = w.Subrepo.Finish(ctx, job.SubmissionID, models.SubmissionRejected, err.Error())
+		}
+	case errors.Is(err, grading.ErrCheckerFailed), job.Attempts >= w.MaxAttempts:
+		// a broken checker won't fix itself by retrying
+		if jobErr = w.Jobrepo.Finish(ctx, job.ID, workerID, models.JobFailed, err.Error()); jobErr == nil {
+			subErr = w.Subrepo.Finish(ctx, job.SubmissionID, models.SubmissionError, err.Error())
+		}
+	default:
+		log.Printf("judge: job %s: %v", job.ID.Hex(), err)
+		next := time.Now().Add(w.Backoff)
+		if jobErr = w.Jobrepo.Retry(ctx, job.ID, workerID, next, err.Error()); jobErr == nil {
+			subErr = w.Subrepo.Retry(ctx, job.SubmissionID, next, err.Error())
+		}
+	}
+	if errors.Is(jobErr, mongo.ErrNoDocuments) {
+		log.Printf("judge: lost lease on job %s", job.ID.Hex())
+		return
+	}
+	if subErr != nil {
+		log.Printf("judge: updating submission %s: %v", job.SubmissionID.Hex(), subErr)
+	}
+	if jobErr != nil {
+		log.Printf("judge: updating job %s: %v", job.ID.Hex(), jobErr)
+	}
+}
+
+// record stores the judgement and settles the submission.
+func (w *JudgeWorker) record(ctx context.Context, submission *models.Submission, problem *models.Problem, result *judge.Result) error {
+	err := w.Subrepo.SaveJudgement(ctx, submission.ID, result.Verdict, result.CompileOutput, result.Tests)
+	if err != nil {
+		return err
+	}
+	if result.Verdict != models.VerdictAccepted {
+		return w.Subrepo.Finish(ctx, submission.ID, models.SubmissionRejected, "")
+	}
+	err = w.Scorer.Accept(ctx, submission, *problem)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return w.Subrepo.Finish(ctx, submission.ID, models.SubmissionRejected, "problem already solved")
+	}
+	return err
+}
+
+// submissionProgress writes test results onto the submission as they come
+// in, so GET /submissions/:id can show how far judging got.
+type submissionProgress struct {
+	ctx        context.Context
+	repo       *repository.SubmissionRepository
+	submission *models.Submission
+}
+
+func (p submissionProgress) Start(total int) {
+	if err := p.repo.StartJudging(p.ctx, p.submission.ID, total); err != nil {
+		log.Printf("judge: updating submission %s: %v", p.submission.ID.Hex(), err)
+	}
+}
+
+func (p submissionProgress) Test(result models.TestResult) {
+	if err := p.repo.AddTestResult(p.ctx, p.submission.ID, result); err != nil {
+		log.Printf("judge: updating submission %s: %v", p.submission.ID.Hex(), err)
+	}
+}
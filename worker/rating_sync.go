@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/codeforces"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+)
+
+// RatingSync periodically mirrors user.rating of every verified Codeforces
+// handle into the rating history.
+type RatingSync struct {
+	Client   *codeforces.Client
+	Userrepo *repository.UserRepository
+	Raterepo *repository.RatingHistoryRepository
+	Interval time.Duration
+}
+
+// NewRatingSync creates a sync running every 12 hours.
+func NewRatingSync(client *codeforces.Client, ur *repository.UserRepository, rr *repository.RatingHistoryRepository) *RatingSync {
+	return &RatingSync{
+		Client:   client,
+		Userrepo: ur,
+		Raterepo: rr,
+		Interval: 12 * time.Hour,
+	}
+}
+
+// Run syncs every user once per Interval until ctx is cancelled.
+func (s *RatingSync) Run(ctx context.Context) {
+	for {
+		users, err := s.Userrepo.GetVerifiedCodeforcesUsers(ctx)
+		if err != nil {
+			log.Println("rating sync: listing users:", err)
+		}
+		for _, user := range users {
+			n, err := s.SyncUser(ctx, user)
+			if err != nil {
+				log.Printf("rating sync: %s: %v", user.CodeforcesUsername, err)
+			} else if n > 0 {
+				log.Printf("rating sync: %s: %d new contests", user.CodeforcesUsername, n)
+			}
+		}
+		if !sleep(ctx, s.Interval) {
+			return
+		}
+	}
+}
+
+// SyncUser mirrors the user's rating history and returns how many contests
+// were new.
+func (s *RatingSync) SyncUser(ctx context.Context, user models.User) (int64, error) {
+	history, err := s.Client.UserRating(ctx, user.CodeforcesUsername)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := make([]models.RatingChange, 0, len(history))
+	for _, h := range history {
+		changes = append(changes, models.RatingChange{
+			UserID:      user.ID,
+			Handle:      h.Handle,
+			ContestID:   h.ContestID,
+			ContestName: h.ContestName,
+			Rank:        h.Rank,
+			OldRating:   h.OldRating,
+			NewRating:   h.NewRating,
+			UpdatedAt:   time.Unix(h.RatingUpdateTimeSeconds, 0),
+		})
+	}
+	return s.Raterepo.UpsertMany(ctx, changes)
+}
@@ -0,0 +1,162 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/codeforces"
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/scoring"
+	"github.com/AbenezerWork/AASTU-CPC/verifier"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// SolveSync periodically pulls user.status for every verified Codeforces
+// handle and records accepted solves of problems in our catalog, so students
+// don't have to validate submissions one by one.
+type SolveSync struct {
+	Client   *codeforces.Client
+	Verifier *verifier.Codeforces
+	Subrepo  *repository.SubmissionRepository
+	Probrepo *repository.ProblemRepository
+	Userrepo *repository.UserRepository
+	Scorer   *scoring.Engine
+
+	// Interval is the pause between two passes over all users.
+	Interval time.Duration
+	// PageSize is how many submissions are requested per user.status call.
+	PageSize int
+}
+
+// NewSolveSync creates a sync with default settings.
+func NewSolveSync(client *codeforces.Client, v *verifier.Codeforces, sr *repository.SubmissionRepository, pr *repository.ProblemRepository, ur *repository.UserRepository, scorer *scoring.Engine) *SolveSync {
+	return &SolveSync{
+		Client:   client,
+		Verifier: v,
+		Subrepo:  sr,
+		Probrepo: pr,
+		Userrepo: ur,
+		Scorer:   scorer,
+		Interval: time.Hour,
+		PageSize: 500,
+	}
+}
+
+// Run syncs every user once per Interval until ctx is cancelled.
+func (s *SolveSync) Run(ctx context.Context) {
+	for {
+		users, err := s.Userrepo.GetVerifiedCodeforcesUsers(ctx)
+		if err != nil {
+			log.Println("solve sync: listing users:", err)
+		}
+		for _, user := range users {
+			n, err := s.SyncUser(ctx, user)
+			if err != nil {
+				log.Printf("solve sync: %s: %v", user.CodeforcesUsername, err)
+			} else if n > 0 {
+				log.Printf("solve sync: %s: recorded %d solves", user.CodeforcesUsername, n)
+			}
+		}
+		if !sleep(ctx, s.Interval) {
+			return
+		}
+	}
+}
+
+// SyncUser records the user's accepted submissions made since the last sync
+// and returns how many solves were recorded.
+func (s *SolveSync) SyncUser(ctx context.Context, user models.User) (int, error) {
+	submissions, err := s.fetchNew(ctx, user.CodeforcesUsername, user.CodeforcesLastSubmissionID)
+	if err != nil {
+		return 0, err
+	}
+
+	// oldest first, so first solves and levels are awarded in order
+	sort.Slice(submissions, func(i, j int) bool { return submissions[i].ID < submissions[j].ID })
+
+	recorded := 0
+	last := user.CodeforcesLastSubmissionID
+	var recordErr error
+	for _, submission := range submissions {
+		var ok bool
+		ok, recordErr = s.record(ctx, user, submission)
+		if recordErr != nil {
+			// resume from this submission next time
+			break
+		}
+		if ok {
+			recorded++
+		}
+		last = submission.ID
+	}
+
+	if last != user.CodeforcesLastSubmissionID {
+		if err := s.Userrepo.SetCodeforcesLastSubmissionID(ctx, user.ID, last); err != nil {
+			return recorded, err
+		}
+	}
+	return recorded, recordErr
+}
+
+// fetchNew pages through user.status, newest first, until it reaches a
+// submission at or below last.
+func (s *SolveSync) fetchNew(ctx context.Context, handle string, last int64) ([]codeforces.Submission, error) {
+	var fresh []codeforces.Submission
+	for from := 1; ; from += s.PageSize {
+		page, err := s.Client.UserStatus(ctx, handle, from, s.PageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, submission := range page {
+			if submission.ID <= last {
+				return fresh, nil
+			}
+			fresh = append(fresh, submission)
+		}
+		if len(page) < s.PageSize {
+			return fresh, nil
+		}
+	}
+}
+
+// record stores submission as a solve if it is an accepted, eligible
+// solution of a catalog problem the user hasn't solved yet.
+func (s *SolveSync) record(ctx context.Context, user models.User, submission codeforces.Submission) (bool, error) {
+	if submission.Verdict != "OK" || submission.ContestID == 0 {
+		return false, nil
+	}
+	if s.Verifier.Policy.Check(submission.Author) != nil {
+		return false, nil
+	}
+
+	problem, err := s.Probrepo.GetBySourceKey(ctx, "codeforces", strconv.Itoa(submission.ContestID), submission.Problem.Index)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// dated by when it was solved, not when the sync noticed, so it counts
+	// in the right leaderboard window
+	solvedAt := time.Unix(submission.CreationTimeSeconds, 0)
+	solve := &models.Submission{
+		UserID:     user.ID.Hex(),
+		ProblemID:  problem.ID.Hex(),
+		Judge:      "codeforces",
+		Submission: strconv.FormatInt(submission.ID, 10),
+		CreatedAt:  solvedAt,
+		VerifiedAt: solvedAt,
+	}
+	err = s.Scorer.Accept(ctx, solve, *problem)
+	if errors.Is(err, repository.ErrDuplicate) {
+		// already solved, or validated by hand before the sync saw it
+		return false, nil
+	}
+	return err == nil, err
+}
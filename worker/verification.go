@@ -0,0 +1,193 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/AbenezerWork/AASTU-CPC/models"
+	"github.com/AbenezerWork/AASTU-CPC/repository"
+	"github.com/AbenezerWork/AASTU-CPC/scoring"
+	"github.com/AbenezerWork/AASTU-CPC/verifier"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// VerificationWorker processes queued submissions, asking the judge each
+// problem comes from whether the submission was accepted.
+type VerificationWorker struct {
+	Subrepo   *repository.SubmissionRepository
+	Probrepo  *repository.ProblemRepository
+	Userrepo  *repository.UserRepository
+	Verifiers *verifier.Registry
+	Scorer    *scoring.Engine
+
+	// Workers is the number of submissions verified concurrently.
+	Workers int
+	// MaxAttempts is how many times a submission is tried before it is
+	// marked as an error.
+	MaxAttempts int
+	// PollInterval is how long an idle worker sleeps before looking again.
+	PollInterval time.Duration
+	// Backoff is the delay before the first retry; it doubles on every
+	// further attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// JobTimeout bounds a single verification.
+	JobTimeout time.Duration
+	// StaleAfter is how long a submission may stay in verifying before it
+	// is assumed abandoned and requeued.
+	StaleAfter time.Duration
+}
+
+// NewVerificationWorker creates a worker with default settings.
+func NewVerificationWorker(sr *repository.SubmissionRepository, pr *repository.ProblemRepository, ur *repository.UserRepository, vr *verifier.Registry, scorer *scoring.Engine) *VerificationWorker {
+	return &VerificationWorker{
+		Subrepo:      sr,
+		Probrepo:     pr,
+		Userrepo:     ur,
+		Verifiers:    vr,
+		Scorer:       scorer,
+		Workers:      2,
+		MaxAttempts:  5,
+		PollInterval: 2 * time.Second,
+		Backoff:      30 * time.Second,
+		MaxBackoff:   30 * time.Minute,
+		JobTimeout:   time.Minute,
+		StaleAfter:   5 * time.Minute,
+	}
+}
+
+// Run processes submissions until ctx is cancelled.
+func (w *VerificationWorker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.requeueStale(ctx)
+	}()
+	wg.Wait()
+}
+
+func (w *VerificationWorker) loop(ctx context.Context) {
+	for {
+		submission, err := w.Subrepo.ClaimNext(ctx)
+		if err == nil {
+			w.process(ctx, submission)
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Println("verification: claiming submission:", err)
+		}
+		if !sleep(ctx, w.PollInterval) {
+			return
+		}
+	}
+}
+
+func (w *VerificationWorker) requeueStale(ctx context.Context) {
+	for {
+		n, err := w.Subrepo.RequeueStale(ctx, time.Now().Add(-w.StaleAfter))
+		if err != nil {
+			log.Println("verification: requeueing stale submissions:", err)
+		} else if n > 0 {
+			log.Printf("verification: requeued %d stale submissions", n)
+		}
+		if !sleep(ctx, w.StaleAfter) {
+			return
+		}
+	}
+}
+
+func (w *VerificationWorker) process(ctx context.Context, submission *models.Submission) {
+	verifyCtx, cancel := context.WithTimeout(ctx, w.JobTimeout)
+	status, problem, err := w.verify(verifyCtx, submission)
+	cancel()
+
+	switch {
+	case status == models.SubmissionPending:
+		next := time.Now().Add(w.backoff(submission.Attempts))
+		err = w.Subrepo.Retry(ctx, submission.ID, next, err.Error())
+	case err != nil:
+		err = w.Subrepo.Finish(ctx, submission.ID, status, err.Error())
+	default:
+		err = w.Scorer.Accept(ctx, submission, *problem)
+		if errors.Is(err, repository.ErrDuplicate) {
+			err = w.Subrepo.Finish(ctx, submission.ID, models.SubmissionRejected, "problem already solved")
+		} else if err != nil {
+			// the verdict is known, only awarding failed; try again later
+			// unless it keeps failing
+			log.Printf("verification: awarding submission %s: %v", submission.ID.Hex(), err)
+			if submission.Attempts >= w.MaxAttempts {
+				err = w.Subrepo.Finish(ctx, submission.ID, models.SubmissionError, err.Error())
+			} else {
+				err = w.Subrepo.Retry(ctx, submission.ID, time.Now().Add(w.backoff(submission.Attempts)), err.Error())
+			}
+		}
+	}
+	if err != nil {
+		log.Printf("verification: updating submission %s: %v", submission.ID.Hex(), err)
+	}
+}
+
+// verify returns the status the submission should move to, the problem it
+// is for and the reason.
+func (w *VerificationWorker) verify(ctx context.Context, submission *models.Submission) (string, *models.Problem, error) {
+	problem, err := w.Probrepo.GetByID(ctx, submission.ProblemID)
+	if err != nil {
+		return w.transient(submission, nil, err)
+	}
+	user, err := w.Userrepo.GetByID(ctx, submission.UserID)
+	if err != nil {
+		return w.transient(submission, problem, err)
+	}
+
+	err = w.Verifiers.Verify(ctx, *problem, submission.Submission, *user)
+	switch {
+	case err == nil:
+		return models.SubmissionAccepted, problem, nil
+	case errors.Is(err, verifier.ErrNotAccepted), errors.Is(err, verifier.ErrUnsupportedJudge), errors.Is(err, verifier.ErrUnverifiedHandle):
+		return models.SubmissionRejected, problem, err
+	default:
+		return w.transient(submission, problem, err)
+	}
+}
+
+// transient schedules a retry unless the submission is out of attempts.
+func (w *VerificationWorker) transient(submission *models.Submission, problem *models.Problem, err error) (string, *models.Problem, error) {
+	if submission.Attempts >= w.MaxAttempts {
+		return models.SubmissionError, problem, err
+	}
+	return models.SubmissionPending, problem, err
+}
+
+func (w *VerificationWorker) backoff(attempts int) time.Duration {
+	d := w.Backoff
+	for i := 1; i < attempts && d < w.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > w.MaxBackoff {
+		d = w.MaxBackoff
+	}
+	return d
+}
+
+// sleep waits for d and reports whether ctx is still live.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}